@@ -35,6 +35,9 @@ are to be interpreted as described in [RFC 2119].
 This package provides basic options for configuring a CORS middleware,
 but more advanced (and potentially dangerous) options can be found in the
 [github.com/jub0bs/fcors/risky] package.
+The parsing and matching logic for origin patterns that this package relies on
+is exposed by the [github.com/jub0bs/fcors/origin] package,
+which is useful for validating origin patterns ahead of building a middleware.
 
 [CORS response headers]: https://developer.mozilla.org/en-US/docs/Web/HTTP/CORS#the_http_response_headers
 [CORS-preflight requests are not authenticated]: https://fetch.spec.whatwg.org/#cors-protocol-and-credentials
@@ -49,7 +52,10 @@ but more advanced (and potentially dangerous) options can be found in the
 package fcors
 
 import (
+	"context"
+	"io/fs"
 	"net/http"
+	"time"
 
 	"github.com/jub0bs/fcors/internal"
 )
@@ -82,11 +88,15 @@ type (
 // The behavior of the resulting middleware is insensitive to the order
 // in which the options that configure it are specified.
 //
-// AllowAccess requires a single call to option [FromOrigins]
-// or option [FromAnyOrigin] as one of its arguments.
+// AllowAccess requires, as one of its arguments, a call to option
+// [FromAnyOrigin] or a call to at least one of the options that specify
+// allowed origins: [FromOrigins], [FromLabeledOrigins], [FromOriginsUntil],
+// [FromOriginsBetween], [FromOriginsForHost], [FromSites],
+// [FromSameSiteOrigins], and [FromLoopbackOrigins].
 //
 // Using a given option more than once in a call to AllowAccess
-// results in a failure to build the corresponding middleware.
+// results in a failure to build the corresponding middleware,
+// unless the uses occur in different bundles (see [Bundle]).
 //
 // If the specified options are invalid or mutually incompatible, AllowAccess
 // returns a nil [Middleware] and some non-nil error. Otherwise, it returns
@@ -103,11 +113,15 @@ func AllowAccess(one OptionAnon, others ...OptionAnon) (Middleware, error) {
 // The behavior of the resulting middleware is insensitive to the order
 // in which the options that configure it are specified.
 //
-// AllowAccessWithCredentials requires a single call to option [FromOrigins]
-// as one of its arguments.
+// AllowAccessWithCredentials requires, as one of its arguments, a call to
+// at least one of the options that specify allowed origins: [FromOrigins],
+// [FromLabeledOrigins], [FromOriginsUntil], [FromOriginsBetween],
+// [FromOriginsForHost], [FromSites], [FromSameSiteOrigins],
+// and [FromLoopbackOrigins].
 //
 // Using a given option more than once in a call to AllowAccessWithCredentials
-// results in a failure to build the corresponding middleware.
+// results in a failure to build the corresponding middleware,
+// unless the uses occur in different bundles (see [Bundle]).
 //
 // If the specified options are invalid or mutually incompatible,
 // AllowAccessWithCredentials returns a nil [Middleware] and some non-nil
@@ -121,6 +135,98 @@ func AllowAccessWithCredentials(one Option, others ...Option) (Middleware, error
 	return internal.NewMiddleware(true, one, others...)
 }
 
+// Bundle groups the specified options under name, so that they can be
+// contributed as a whole to a CORS policy (e.g. by a platform library)
+// and composed with options contributed elsewhere (e.g. by each service).
+// Bundles may be nested; the name of a nested bundle is qualified by
+// the name of its enclosing bundle (e.g. "platform/monitoring").
+//
+// The following options are additive across bundles: if used in
+// different bundles (or both in some bundle and outside any bundle),
+// their effects get merged.
+//
+//   - [FromOrigins]
+//   - [WithMethods]
+//   - [WithRequestHeaders]
+//   - [ExposeResponseHeaders]
+//
+// The following options are scalar: they may be used in different bundles
+// only with the same value; otherwise, the resulting error names the
+// conflicting bundles.
+//
+//   - [MaxAgeInSeconds]
+//   - [PreflightSuccessStatus]
+//
+// Using any of those options more than once within a given bundle, or
+// any other option more than once in a given policy (regardless of bundles),
+// results in a failure to build the corresponding middleware.
+// Bundle names must be non-empty, contain at most 64 visible ASCII
+// characters, and be unique within a policy.
+//
+// Any occurrence of a nil option results in a panic.
+func Bundle(name string, one Option, others ...Option) Option {
+	return internal.Bundle(name, one, others...)
+}
+
+// BundleAnon works like [Bundle] does, but for options that are specific to
+// [AllowAccess] (e.g. [FromAnyOrigin]).
+//
+// Any occurrence of a nil option results in a panic.
+func BundleAnon(name string, one OptionAnon, others ...OptionAnon) OptionAnon {
+	return internal.BundleAnon(name, one, others...)
+}
+
+// A Warning describes a questionable aspect of a CORS policy that is
+// nonetheless valid. Its Code field identifies the kind of problem
+// (see the Warning* constants) and its Message field describes the problem.
+type Warning = internal.Warning
+
+// Codes of the warnings that [Lint] and [LintWithCredentials] may report.
+const (
+	// an origin pattern is encompassed by another origin pattern
+	WarningRedundantOriginPattern = internal.WarnRedundantOriginPattern
+	// the max-age value exceeds the cap of some major browsers
+	WarningMaxAgeAboveBrowserCap = internal.WarnMaxAgeAboveBrowserCap
+	// an exposed response header likely carries secrets
+	WarningSensitiveExposedHeader = internal.WarnSensitiveExposedHeader
+	// some insecure origins are allowed
+	WarningInsecureOrigins = internal.WarnInsecureOrigins
+	// more than 100 origin patterns are specified
+	WarningLargeAllowlist = internal.WarnLargeAllowlist
+)
+
+// Lint reports questionable aspects of the CORS policy described by the
+// specified options, which Lint accepts and validates exactly like
+// [AllowAccess] does.
+// If the options are invalid or mutually incompatible, Lint returns a nil
+// slice and the error that AllowAccess would return. Otherwise, it returns
+// warnings (if any) and a nil error. Warnings never prevent a middleware
+// from being built; rather, they are meant to be surfaced in tests or
+// in deployment tooling.
+//
+// Lint reports, in particular,
+//
+//   - origin patterns that are encompassed by other origin patterns
+//     (e.g. https://foo.example.com alongside https://*.example.com);
+//   - max-age values beyond which Chromium (7200) ignores them;
+//   - exposed response headers that likely carry secrets
+//     (e.g. Authorization or X-CSRF-Token);
+//   - insecure origins (e.g. http://example.com);
+//   - allowlists of more than 100 origin patterns.
+//
+// Any occurrence of a nil option results in a panic.
+func Lint(one OptionAnon, others ...OptionAnon) ([]Warning, error) {
+	return internal.Lint(false, one, others...)
+}
+
+// LintWithCredentials works like [Lint] does, but accepts and validates
+// options exactly like [AllowAccessWithCredentials] does.
+//
+// Any occurrence of a nil option results in a panic.
+func LintWithCredentials(one Option, others ...Option) ([]Warning, error) {
+	return internal.Lint(true, one, others...)
+}
+
 // FromOrigins configures a CORS middleware to allow access from any of the
 // [Web origins] encompassed by the specified origin patterns.
 //
@@ -136,11 +242,19 @@ func AllowAccessWithCredentials(one Option, others ...Option) (Middleware, error
 //	https://example.com            // permitted
 //	chrome-extension://example.com // prohibited
 //
-// Origins must be specified in [ASCII serialized form]; Unicode is prohibited:
+// Hosts may be specified either in ASCII form or in Unicode form;
+// the latter get converted to the former, since browsers use
+// the [ASCII serialized form] of origins:
 //
 //	https://example.com            // permitted
 //	https://www.xn--xample-9ua.com // permitted (Punycode)
-//	https://www.éxample.com        // prohibited (Unicode)
+//	https://www.éxample.com        // permitted (same as the previous one)
+//
+// However, in order to mitigate [homograph attacks],
+// hosts containing labels that mix characters from different scripts
+// are prohibited:
+//
+//	https://раypal.com // prohibited (mix of Cyrillic and Latin)
 //
 // For [security reasons], the [null origin] is prohibited.
 //
@@ -155,6 +269,20 @@ func AllowAccessWithCredentials(one Option, others ...Option) (Middleware, error
 //	http://[0:0:0:0:0:0:0:0001]:9090                      // prohibited
 //	http://[0000:0000:0000:0000:0000:0000:0000:0001]:9090 // prohibited
 //
+// Hosts may also be IP prefixes specified in [CIDR notation],
+// in which case the prefix length must follow the IP address
+// and the IP address must not have any bits set beyond the prefix length:
+//
+//	http://192.168.0.0/16:* // permitted
+//	http://[fd00::]/8:9090  // permitted
+//	http://192.168.1.0/16   // prohibited (bits set beyond the prefix length)
+//	http://[fd00::/8]:9090  // prohibited (prefix length inside brackets)
+//
+// Such a pattern encompasses all the origins whose host is an IP address
+// that belongs to the prefix. An IP prefix is deemed a [loopback IP address]
+// only if all the IP addresses it encompasses are loopback addresses
+// (e.g. 127.0.0.0/8).
+//
 // Valid port values range from 1 to 65,535 (inclusive):
 //
 //	https://example.com       // permitted (no port)
@@ -199,12 +327,19 @@ func AllowAccessWithCredentials(one Option, others ...Option) (Middleware, error
 //	http://localhost:9090
 //
 // Specifying both arbitrary subdomains and arbitrary ports
-// in a given origin pattern is prohibited:
+// in a given origin pattern is by default prohibited:
 //
 //	https://*.example.com       // permitted
 //	https://*.example.com:9090  // permitted
 //	https://example.com:*       // permitted
-//	https://*.example.com:*     // prohibited
+//	https://*.example.com:*     // prohibited (by default)
+//
+// If you need to deliberately specify such origin patterns (danger!),
+// you must also activate option
+// [github.com/jub0bs/fcors/risky.DangerouslyTolerateSubdomainsWithArbitraryPorts].
+// Any occurrence of such a prohibited origin pattern without activating option
+// [github.com/jub0bs/fcors/risky.DangerouslyTolerateSubdomainsWithArbitraryPorts]
+// results in a failure to build the corresponding middleware.
 //
 // No other types of origin patterns are supported. In particular,
 // an origin pattern consisting of a single asterisk is prohibited.
@@ -231,6 +366,11 @@ func AllowAccessWithCredentials(one Option, others ...Option) (Middleware, error
 //	https://*.com          // prohibited (by default): com is a public suffix
 //	https://*.github.io    // prohibited (by default): github.io is a public suffix
 //
+// Which domains count as public suffixes is determined by the list maintained
+// at https://publicsuffix.org/, which you can complement
+// with your own public suffixes by activating option
+// [AdditionalPublicSuffixes].
+//
 // If you need to deliberately allow arbitrary subdomains of a
 // public suffix (danger!), you must also activate option
 // [github.com/jub0bs/fcors/risky.DangerouslyTolerateSubdomainsOfPublicSuffixes].
@@ -238,11 +378,18 @@ func AllowAccessWithCredentials(one Option, others ...Option) (Middleware, error
 // [github.com/jub0bs/fcors/risky.DangerouslyTolerateSubdomainsOfPublicSuffixes]
 // results in a failure to build the corresponding middleware.
 //
+// Errors about invalid origin patterns can be inspected via
+// [github.com/jub0bs/fcors/origin.InvalidPatternError]; where possible,
+// they diagnose common mistakes (e.g. a trailing slash, a path, uppercase
+// letters, or a missing scheme) and suggest a corrected origin pattern.
+//
 // [ASCII serialized form]: https://html.spec.whatwg.org/multipage/browsers.html#ascii-serialisation-of-an-origin
+// [CIDR notation]: https://www.rfc-editor.org/rfc/rfc4632#section-3.1
 // [Private-Network Access]: https://wicg.github.io/private-network-access/
 // [Web origins]: https://developer.mozilla.org/en-US/docs/Glossary/Origin
 // [compressed form]: https://datatracker.ietf.org/doc/html/rfc5952
 // [dotted-quad notation]: https://en.wikipedia.org/wiki/Dot-decimal_notation
+// [homograph attacks]: https://en.wikipedia.org/wiki/IDN_homograph_attack
 // [loopback IP address]: https://www.rfc-editor.org/rfc/rfc5735#section-3
 // [null origin]: https://fetch.spec.whatwg.org/#append-a-request-origin-header
 // [public suffix]: https://publicsuffix.org/
@@ -251,6 +398,379 @@ func FromOrigins(one string, others ...string) Option {
 	return internal.FromOrigins(one, others...)
 }
 
+// ExceptOrigins configures a CORS middleware to deny access from the
+// [Web origins] encompassed by the specified origin patterns,
+// even if those origins are encompassed by the origin patterns specified
+// in option [FromOrigins]. In other words, the origins allowed by the
+// resulting middleware are those allowed by option [FromOrigins]
+// minus those denied by this option. For instance,
+//
+//	fcors.FromOrigins("https://*.example.com"),
+//	fcors.ExceptOrigins(
+//	  "https://uploads.example.com",
+//	  "https://*.sandbox.example.com",
+//	),
+//
+// allows https://foo.example.com but denies
+// https://uploads.example.com and https://bar.sandbox.example.com.
+//
+// The origin patterns specified in this option are subject to the same
+// syntax rules as those specified in option [FromOrigins].
+// However, because they deny rather than allow access,
+// they are exempt from the restrictions about insecure origins
+// and public suffixes that apply to option [FromOrigins].
+//
+// Exclusions take precedence over all the options that allow origins,
+// including option [FromSameSiteOrigins].
+//
+// Using this option without option [FromOrigins] (or another option that
+// allows origins) results in a failure to build the corresponding
+// middleware; so does using this option in conjunction with option
+// [FromAnyOrigin] in a call to [AllowAccess].
+// Moreover, because it is likely to be the result of a mistake,
+// any occurrence of an origin pattern that does not overlap any of the
+// allowed origin patterns results in a failure to build the corresponding
+// middleware. In conjunction with option [FromSameSiteOrigins], any https
+// origin pattern is deemed to overlap the allowed origins, since which
+// origins are same site depends on the request.
+//
+// [Web origins]: https://developer.mozilla.org/en-US/docs/Glossary/Origin
+func ExceptOrigins(one string, others ...string) Option {
+	return internal.ExceptOrigins(one, others...)
+}
+
+// FromLabeledOrigins configures a CORS middleware to allow access from the
+// [Web origins] encompassed by the specified origin patterns and to tag
+// those origins with the specified label (e.g. "partner:acme", "internal",
+// or "mobile-webview"). When such an origin is allowed, the label is made
+// available to the wrapped handler via the request's context (see function
+// [OriginLabel]) and recorded in the request's [Decision] (if any).
+// Labels are useful, for instance, for slicing metrics by partner
+// without resorting to high-cardinality raw origins.
+//
+// This option can be used multiple times, but only once per label.
+// If an origin is encompassed by the origin patterns of several labels,
+// the label that was specified first prevails. The label must consist of
+// between 1 and 64 visible ASCII characters. The origin patterns are subject
+// to the same syntax rules and restrictions as those specified in option
+// [FromOrigins]; to ease troubleshooting, errors about them name the label
+// in question. Moreover, using this option in conjunction with option
+// [FromAnyOrigin] in a call to [AllowAccess] results in a failure to build
+// the corresponding middleware.
+//
+// [Web origins]: https://developer.mozilla.org/en-US/docs/Glossary/Origin
+func FromLabeledOrigins(label string, one string, others ...string) Option {
+	return internal.FromLabeledOrigins(label, one, others...)
+}
+
+// OriginLabel returns the label that option [FromLabeledOrigins] associates
+// with the origin of the request whose context is ctx. The boolean result
+// is false if that request is not a CORS request from an allowed origin
+// that has a label.
+//
+// Only the handlers wrapped by a CORS middleware can observe the label,
+// which they do for CORS-preflight requests only if option
+// [PreflightPassthrough] is used. Middleware stacked on top of a CORS
+// middleware should use [RecordDecision] instead.
+func OriginLabel(ctx context.Context) (string, bool) {
+	return internal.OriginLabel(ctx)
+}
+
+// A Decision records the outcome of a CORS middleware's processing of
+// a request (including a CORS-preflight request); see [RecordDecision].
+// Its OriginLabel field contains the label (if any) that option
+// [FromLabeledOrigins] associates with the request's origin.
+// Its PreflightFailure field contains the reason (if any) why
+// a CORS-preflight request failed, which lets logging and metrics
+// middleware tell apart, for instance, a preflight rejected because of
+// its origin from one rejected (because of option [EnforceRequestHeaders])
+// because of its request headers, even though both elicit a 403 response
+// (which the middleware sends itself, even if option [PreflightPassthrough]
+// is used). Preflights that fail for other reasons elicit an ok status
+// (that of the wrapped handler, under option [PreflightPassthrough]),
+// because the absence of CORS headers suffices for browsers to deny access.
+// Note that a CORS middleware records only the failures that it detects
+// itself; for instance, a CORS middleware that lists the allowed methods
+// in the response for any non-safelisted method leaves it to the browser
+// to fail a preflight for a disallowed method.
+type Decision = internal.Decision
+
+// A PreflightFailure is a reason why a CORS-preflight request failed;
+// see [Decision].
+type PreflightFailure = internal.PreflightFailure
+
+// The reasons why a CORS-preflight request may fail.
+const (
+	// The request's origin is not allowed.
+	OriginNotAllowed = internal.OriginNotAllowed
+	// The request asks for Private-Network Access, which is not allowed.
+	PrivateNetworkAccessNotAllowed = internal.PrivateNetworkAccessNotAllowed
+	// The request's method is not allowed.
+	MethodNotAllowed = internal.MethodNotAllowed
+	// Some of the request's headers are not allowed.
+	RequestHeadersNotAllowed = internal.RequestHeadersNotAllowed
+)
+
+// RecordDecision returns a copy of ctx and a pointer to a [Decision]
+// in which a CORS middleware records the outcome of its processing
+// of a request whose context is (or derives from) the resulting context.
+// RecordDecision is meant for middleware (e.g. for logging or metrics)
+// stacked on top of a CORS middleware, which the CORS protocol otherwise
+// leaves in the dark, as in
+//
+//	func withMetrics(h http.Handler) http.Handler {
+//	  f := func(w http.ResponseWriter, r *http.Request) {
+//	    ctx, d := fcors.RecordDecision(r.Context())
+//	    h.ServeHTTP(w, r.WithContext(ctx))
+//	    // record a metric tagged with d.OriginLabel
+//	  }
+//	  return http.HandlerFunc(f)
+//	}
+//
+// The Decision must not be read before the CORS middleware returns.
+func RecordDecision(ctx context.Context) (context.Context, *Decision) {
+	return internal.RecordDecision(ctx)
+}
+
+// FromOriginsUntil configures a CORS middleware to allow access from the
+// [Web origins] encompassed by the specified origin patterns, but only
+// until the specified time (exclusive); after that, the origin patterns
+// stop matching automatically, without any need to rebuild the middleware.
+// This option is useful for granting temporary access, e.g. to partners
+// during a limited pilot. For instance,
+//
+//	pilotEnd := time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC)
+//	fcors.FromOriginsUntil(pilotEnd, "https://partner.example.com")
+//
+// The origin patterns are subject to the same syntax rules and restrictions
+// as those specified in option [FromOrigins], and they complement the
+// origins allowed by other options. Unlike option [FromOrigins], this
+// option can be used multiple times (e.g. once per partner). Note that
+// a middleware whose time-bounded origin patterns have all expired still
+// builds successfully, lest a mere restart of your server fail.
+//
+// The current time is provided by [time.Now] unless option [WithClock] is
+// used. Using this option in conjunction with option [FromAnyOrigin]
+// in a call to [AllowAccess] results in a failure to build
+// the corresponding middleware.
+//
+// To find out (e.g. in a metric) how soon time-bounded origin patterns
+// expire, see [ValidityWindows] and [ValidityWindowsWithCredentials].
+//
+// [Web origins]: https://developer.mozilla.org/en-US/docs/Glossary/Origin
+func FromOriginsUntil(notAfter time.Time, one string, others ...string) Option {
+	return internal.FromOriginsUntil(notAfter, one, others...)
+}
+
+// FromOriginsBetween is like [FromOriginsUntil] but also specifies
+// the time (inclusive) from which the origin patterns start matching.
+// Specifying a validity window whose start is not before its end results
+// in a failure to build the corresponding middleware.
+func FromOriginsBetween(notBefore, notAfter time.Time, one string, others ...string) Option {
+	return internal.FromOriginsBetween(notBefore, notAfter, one, others...)
+}
+
+// WithClock configures a CORS middleware to obtain the current time from
+// now rather than from [time.Now]. The current time only matters to the
+// origin patterns specified in options [FromOriginsUntil] and
+// [FromOriginsBetween]. This option is mostly useful for testing.
+// Specifying a nil function results in a failure to build
+// the corresponding middleware.
+func WithClock(now func() time.Time) Option {
+	return internal.WithClock(now)
+}
+
+// A ValidityWindow describes the origin patterns specified in an occurrence
+// of option [FromOriginsUntil] or option [FromOriginsBetween], along with
+// their validity window. Its Remaining method reports how soon those
+// origin patterns expire.
+type ValidityWindow = internal.ValidityWindow
+
+// ValidityWindows reports the validity windows of the time-bounded origin
+// patterns in the CORS policy described by the specified options, which
+// ValidityWindows accepts and validates exactly like [AllowAccess] does.
+// If the options are invalid or mutually incompatible, ValidityWindows
+// returns a nil slice and the error that AllowAccess would return.
+// Otherwise, it returns one validity window per occurrence of option
+// [FromOriginsUntil] or option [FromOriginsBetween], in the order in which
+// those occurrences were specified, and a nil error.
+//
+// This function is meant for policy introspection, e.g. for exporting,
+// as a metric, how soon each time-bounded origin pattern expires:
+//
+//	windows, err := fcors.ValidityWindows(opts[0], opts[1:]...)
+//	// handle err
+//	for _, vw := range windows {
+//	  remaining := vw.Remaining(time.Now())
+//	  // record remaining for vw.Patterns
+//	}
+//
+// Any occurrence of a nil option results in a panic.
+func ValidityWindows(one OptionAnon, others ...OptionAnon) ([]ValidityWindow, error) {
+	return internal.ValidityWindows(false, one, others...)
+}
+
+// ValidityWindowsWithCredentials works like [ValidityWindows] does, but
+// accepts and validates options exactly like [AllowAccessWithCredentials]
+// does.
+//
+// Any occurrence of a nil option results in a panic.
+func ValidityWindowsWithCredentials(one Option, others ...Option) ([]ValidityWindow, error) {
+	return internal.ValidityWindows(true, one, others...)
+}
+
+// FromOriginsForHost configures a CORS middleware to allow access from the
+// [Web origins] encompassed by the specified origin patterns, but only for
+// requests that target the specified host (as indicated by their Host
+// header, regardless of port). This option is useful for multi-tenant
+// services where each tenant has its own API domain and its own front-end
+// origins; for instance,
+//
+//	fcors.FromOriginsForHost("api.customer-a.com", "https://app.customer-a.com"),
+//	fcors.FromOriginsForHost("api.customer-b.com", "https://*.customer-b.com"),
+//
+// allows https://app.customer-a.com to access api.customer-a.com but not
+// api.customer-b.com. This option can be used multiple times, but only once
+// per host. Requests that target a host not specified in any occurrence of
+// this option fall back to the origins allowed by option [FromOrigins]
+// (if any); otherwise, they are denied.
+//
+// The host must be a domain (in ASCII or Unicode form) or an IP address
+// (IPv6 addresses may be enclosed in brackets or not), without any port.
+// The origin patterns are subject to the same syntax
+// rules and restrictions as those specified in option [FromOrigins];
+// to ease troubleshooting, errors about them name the host in question.
+// Moreover, using this option in conjunction with option [FromAnyOrigin]
+// in a call to [AllowAccess] results in a failure to build
+// the corresponding middleware.
+//
+// [Web origins]: https://developer.mozilla.org/en-US/docs/Glossary/Origin
+func FromOriginsForHost(host string, one string, others ...string) Option {
+	return internal.FromOriginsForHost(host, one, others...)
+}
+
+// FromSites configures a CORS middleware to allow access from the
+// [Web origins] of the specified sites, i.e. from the https origins whose
+// host is one of the specified domains or one of their subdomains
+// (on the default port). For instance,
+//
+//	fcors.FromSites("example.com")
+//
+// is equivalent to
+//
+//	fcors.FromOrigins("https://example.com", "https://*.example.com")
+//
+// Each site must be a domain (in ASCII or Unicode form);
+// IP addresses, schemes, ports, and wildcards are prohibited.
+// Because the resulting origin patterns encompass arbitrary subdomains,
+// they are subject to the same restriction about [public suffixes]
+// as those specified in option [FromOrigins]; in particular,
+//
+//	fcors.FromSites("co.uk")
+//
+// results (by default) in a failure to build the corresponding middleware.
+//
+// This option can be used in conjunction with option [FromOrigins].
+// However, using it in conjunction with option [FromAnyOrigin]
+// in a call to [AllowAccess] results in a failure to build
+// the corresponding middleware.
+//
+// [Web origins]: https://developer.mozilla.org/en-US/docs/Glossary/Origin
+// [public suffixes]: https://publicsuffix.org/
+func FromSites(one string, others ...string) Option {
+	return internal.FromSites(one, others...)
+}
+
+// FromSameSiteOrigins configures a CORS middleware to allow access from
+// the https [Web origins] that are [same site] with the host targeted by
+// the request, i.e. whose host has the same registrable domain
+// (also known as "eTLD+1") as the request's Host header.
+// For instance, a request that targets host api.example.com is allowed
+// from https://example.com, https://www.example.com, and
+// https://foo.bar.example.com:8443, but not from http://www.example.com or
+// https://example.org. Which domains count as [public suffixes]
+// is determined in the same way as for option [FromOrigins] and is
+// affected by option [AdditionalPublicSuffixes].
+// This option is useful for deployments that serve several brands, each
+// on its own domain, without having to enumerate all of those domains.
+//
+// Requests whose host is an IP address or a public suffix are not deemed
+// same site with any origin. By default, the host targeted by a request
+// is taken from its Host header only; if your server sits behind a proxy
+// that sets the X-Forwarded-Host header, see option
+// [github.com/jub0bs/fcors/risky.TrustXForwardedHost].
+//
+// This option can be used in conjunction with option [FromOrigins].
+// Origins denied by option [ExceptOrigins] are denied even if they are
+// same site with the host targeted by the request.
+// However, using this option in conjunction with option [FromAnyOrigin]
+// in a call to [AllowAccess] results in a failure to build
+// the corresponding middleware.
+//
+// [Web origins]: https://developer.mozilla.org/en-US/docs/Glossary/Origin
+// [public suffixes]: https://publicsuffix.org/
+// [same site]: https://html.spec.whatwg.org/multipage/browsers.html#same-site
+func FromSameSiteOrigins() Option {
+	return internal.FromSameSiteOrigins()
+}
+
+// FromLoopbackOrigins configures a CORS middleware to allow access from all
+// the [Web origins] whose host is localhost or a [loopback IP address],
+// regardless of their scheme (http or https) and port. This option is meant
+// for local development only; it is roughly equivalent to
+//
+//	fcors.FromOrigins(
+//	  "http://localhost:*",
+//	  "https://localhost:*",
+//	  "http://127.0.0.0/8:*",
+//	  "http://[::1]:*",
+//	)
+//
+// but also covers the https origins whose host is a loopback IP address.
+//
+// So that this option never ends up in production by accident,
+// using it results in a failure to build the corresponding middleware
+// unless the program was built with build tag fcorsdev, as in
+//
+//	go build -tags fcorsdev
+//
+// or the environment variable FCORS_DEV is set to a value that
+// [strconv.ParseBool] interprets as true.
+//
+// This option can be used in conjunction with option [FromOrigins].
+// However, using it in conjunction with option [FromAnyOrigin]
+// in a call to [AllowAccess] results in a failure to build
+// the corresponding middleware.
+//
+// [Web origins]: https://developer.mozilla.org/en-US/docs/Glossary/Origin
+// [loopback IP address]: https://www.rfc-editor.org/rfc/rfc5735#section-3
+func FromLoopbackOrigins() Option {
+	return internal.FromLoopbackOrigins()
+}
+
+// AdditionalPublicSuffixes configures a CORS middleware to treat as
+// [public suffixes], in addition to those listed at https://publicsuffix.org/,
+// the domains listed in the file named name in file system fsys.
+// That file must follow [the format of the Public Suffix List].
+// This option is useful, for instance, for preventing origin patterns like
+//
+//	https://*.apps.internal-paas.corp
+//
+// where apps.internal-paas.corp is a domain under which anyone in your
+// organization can provision a subdomain, from being specified in option
+// [FromOrigins]. Note that this option can only make the prohibition on
+// subdomains of public suffixes stricter, never laxer.
+//
+// Any failure to read or parse the file results in a failure to build
+// the corresponding middleware; so does using this option in conjunction
+// with option [github.com/jub0bs/fcors/risky.ReplacePublicSuffixList].
+//
+// [public suffixes]: https://publicsuffix.org/
+// [the format of the Public Suffix List]: https://github.com/publicsuffix/list/wiki/Format
+func AdditionalPublicSuffixes(fsys fs.FS, name string) Option {
+	return internal.AdditionalPublicSuffixes(fsys, name)
+}
+
 // FromAnyOrigin configures a CORS middleware to allow any Web origin.
 //
 // Using this option in conjunction with option [FromOrigins]
@@ -342,6 +862,18 @@ func WithAnyMethod() Option {
 // to allow all request headers, use option [WithAnyRequestHeaders]
 // instead of this one.
 //
+// A name that ends with a * (e.g. "x-amz-*") is a name pattern that
+// encompasses all request-header names that start with what precedes the *
+// (e.g. "x-amz-date" and "x-amz-content-sha256").
+// Such patterns are useful for allowing families of request headers whose
+// exact composition you don't control (e.g. those sent by vendor SDKs).
+// Name patterns that encompass some forbidden or prohibited request-header
+// names (e.g. "sec-*", "proxy-*", or "access-control-allow-*") result in a failure
+// to build the corresponding middleware.
+// When name patterns are specified, responses to CORS-preflight requests
+// list exactly the request headers that the client intends to use,
+// if all of them are allowed, and none otherwise.
+//
 // [forbidden request-header names]: https://fetch.spec.whatwg.org/#forbidden-request-header
 // [invalid header name]: https://datatracker.ietf.org/doc/html/rfc7230#section-3.2
 func WithRequestHeaders(one string, others ...string) Option {
@@ -358,6 +890,82 @@ func WithAnyRequestHeaders() Option {
 	return internal.WithAnyRequestHeaders()
 }
 
+// WithRequestHeadersForMethod configures a CORS middleware to allow the
+// specified request headers, but only for requests that use the specified
+// method. Request headers allowed by option [WithRequestHeaders] remain
+// allowed for all methods.
+// In response to a CORS-preflight request whose
+// [Access-Control-Request-Method] header specifies method, the middleware
+// lists exactly the request headers that the client intends to use, if all of
+// them are allowed for that method, and none otherwise.
+//
+// This option can be used multiple times, but at most once per method.
+// Unlike header names, method names are case-sensitive.
+// The specified method must be allowed, either because it is
+// [CORS-safelisted] or by option [WithMethods] or option [WithAnyMethod];
+// otherwise, building the corresponding middleware fails.
+// The specified header names are subject to the same restrictions as in
+// option [WithRequestHeaders], except that name patterns are not supported.
+//
+// Using this option in conjunction with option [WithAnyRequestHeaders] or
+// option [WithAnyRequestHeadersExcept] in a call to [AllowAccess] or
+// [AllowAccessWithCredentials] results in a failure to build the
+// corresponding middleware.
+//
+// [Access-Control-Request-Method]: https://fetch.spec.whatwg.org/#http-access-control-request-method
+// [CORS-safelisted]: https://fetch.spec.whatwg.org/#cors-safelisted-method
+func WithRequestHeadersForMethod(method string, one string, others ...string) Option {
+	return internal.WithRequestHeadersForMethod(method, one, others...)
+}
+
+// WithAnyRequestHeadersExcept configures a CORS middleware to allow any
+// request headers other than the specified ones.
+// Unlike option [WithAnyRequestHeaders] in conjunction with [AllowAccess],
+// this option doesn't rely on the wildcard; instead, responses to
+// CORS-preflight requests list exactly the request headers that the client
+// intends to use, if none of them is denied, and none otherwise.
+// Therefore, this option works the same way in conjunction with [AllowAccess]
+// as it does with [AllowAccessWithCredentials].
+//
+// Using this option in conjunction with option [WithRequestHeaders] or option
+// [WithAnyRequestHeaders] in a call to [AllowAccess] or
+// [AllowAccessWithCredentials] results in a failure to build the
+// corresponding middleware.
+//
+// Any occurrence of an [invalid header name] (or of a literal *) results in
+// a failure to build the corresponding middleware.
+//
+// Header names are case-insensitive.
+//
+// [invalid header name]: https://datatracker.ietf.org/doc/html/rfc7230#section-3.2
+func WithAnyRequestHeadersExcept(one string, others ...string) Option {
+	return internal.WithAnyRequestHeadersExcept(one, others...)
+}
+
+// EnforceRequestHeaders configures a CORS middleware to check, in
+// CORS-preflight requests, the request headers that the client intends to use
+// (as listed in the [Access-Control-Request-Headers] header) against the
+// request headers allowed by option [WithRequestHeaders], and to explicitly
+// reject (with status 403) CORS-preflight requests that list some disallowed
+// request header or whose Access-Control-Request-Headers header is malformed.
+// Such rejections are recorded as [RequestHeadersNotAllowed] in the request's
+// [Decision] (if any), which distinguishes them from rejections of disallowed
+// origins. Even if option [PreflightPassthrough] is used, the middleware
+// itself responds to such requests, without invoking the wrapped handler.
+//
+// By default, CORS middleware simply list all allowed request headers in
+// their responses to CORS-preflight requests and let browsers fail those
+// requests that list some disallowed request header.
+//
+// Using this option in conjunction with option [WithAnyRequestHeaders] in a
+// call to [AllowAccess] or [AllowAccessWithCredentials] results in a failure
+// to build the corresponding middleware.
+//
+// [Access-Control-Request-Headers]: https://fetch.spec.whatwg.org/#http-access-control-request-headers
+func EnforceRequestHeaders() Option {
+	return internal.EnforceRequestHeaders()
+}
+
 // MaxAgeInSeconds configures a CORS middleware to instruct browsers to
 // cache preflight responses for a maximum duration of delta seconds.
 //
@@ -416,6 +1024,30 @@ func ExposeResponseHeaders(one string, others ...string) Option {
 	return internal.ExposeResponseHeaders(one, others...)
 }
 
+// ExposeResponseHeadersAutomatically configures a CORS middleware to expose
+// to clients the response headers that the wrapped handler actually sets.
+// When access is granted, the middleware wraps the [http.ResponseWriter]
+// passed to the handler; at the time the response status gets written,
+// it computes the [Access-Control-Expose-Headers] header from the response
+// headers set so far, except [CORS-safelisted response-header names],
+// forbidden ones (e.g. Set-Cookie), Vary, and the headers of the CORS
+// protocol. The wrapper supports [http.Flusher], [http.Hijacker], and
+// [http.ResponseController] (via an Unwrap method).
+//
+// Unlike option [ExposeAllResponseHeaders], this option is available
+// in conjunction with [AllowAccessWithCredentials], in which the
+// wildcard cannot be used.
+//
+// Using this option in conjunction with option [ExposeResponseHeaders] or
+// option [ExposeAllResponseHeaders] results in a failure to build the
+// corresponding middleware.
+//
+// [Access-Control-Expose-Headers]: https://fetch.spec.whatwg.org/#http-access-control-expose-headers
+// [CORS-safelisted response-header names]: https://fetch.spec.whatwg.org/#cors-safelisted-response-header-name
+func ExposeResponseHeadersAutomatically() Option {
+	return internal.ExposeResponseHeadersAutomatically()
+}
+
 // ExposeAllResponseHeaders configures a CORS middleware to expose all
 // response headers to clients.
 //
@@ -426,6 +1058,33 @@ func ExposeAllResponseHeaders() OptionAnon {
 	return internal.ExposeAllResponseHeaders()
 }
 
+// AssumeNoWildcardSupport configures a CORS middleware to eschew the
+// wildcard in the [Access-Control-Allow-Methods],
+// [Access-Control-Allow-Headers], and [Access-Control-Expose-Headers]
+// headers, for the benefit of clients (e.g. older versions of Safari and
+// some embedded webviews) that don't honor it there.
+//
+// More specifically, in conjunction with option [WithAnyMethod] or option
+// [WithAnyRequestHeaders], the middleware reflects the method or the
+// request-header names listed in CORS-preflight requests, as it does
+// in conjunction with [AllowAccessWithCredentials]; and in conjunction with
+// option [ExposeAllResponseHeaders], the middleware exposes the response
+// headers that the wrapped handler actually sets, as described in
+// [ExposeResponseHeadersAutomatically]. The Vary header is unaffected,
+// since preflight responses always vary on the request's method and
+// headers.
+//
+// Using this option has no effect on the [Access-Control-Allow-Origin]
+// header, since support for the wildcard in that header is universal.
+//
+// [Access-Control-Allow-Headers]: https://fetch.spec.whatwg.org/#http-access-control-allow-headers
+// [Access-Control-Allow-Methods]: https://fetch.spec.whatwg.org/#http-access-control-allow-methods
+// [Access-Control-Allow-Origin]: https://fetch.spec.whatwg.org/#http-access-control-allow-origin
+// [Access-Control-Expose-Headers]: https://fetch.spec.whatwg.org/#http-access-control-expose-headers
+func AssumeNoWildcardSupport() OptionAnon {
+	return internal.AssumeNoWildcardSupport()
+}
+
 // PreflightSuccessStatus configures a CORS middleware to use the specified
 // status code in successful preflight responses.
 //
@@ -440,3 +1099,91 @@ func ExposeAllResponseHeaders() OptionAnon {
 func PreflightSuccessStatus(code uint) Option {
 	return internal.PreflightSuccessStatus(code)
 }
+
+// PreflightPassthrough configures a CORS middleware to pass CORS-preflight
+// requests through to the wrapped handler, which remains responsible for
+// the status and body of preflight responses. The middleware still writes
+// the CORS headers of preflight responses (including those related to
+// Private-Network Access, if enabled) before invoking the handler.
+// CORS-preflight requests that the middleware explicitly rejects with
+// status 403 (those from disallowed origins and, if option
+// [EnforceRequestHeaders] is used, those that list some disallowed request
+// header) are not passed through: the middleware responds to them itself.
+// For other failed preflights, the handler is invoked all the same, and
+// the absence of the CORS headers causes the browser to deny access.
+//
+// This option is intended for handlers that implement their own logic for
+// OPTIONS requests. Note that the wrapped handler should respond with a
+// status in the [2xx range] to successful preflight requests.
+// If option [PreflightSuccessStatus] is also used, the status it specifies
+// is the one used in preflight responses for which the wrapped handler
+// doesn't explicitly write a status.
+//
+// [2xx range]: https://fetch.spec.whatwg.org/#ok-status
+func PreflightPassthrough() Option {
+	return internal.PreflightPassthrough()
+}
+
+// PreventSharedCaching configures a CORS middleware to prevent shared caches
+// (e.g. CDNs) from storing responses whose CORS headers depend on the
+// request, which protects you against cache poisoning by caches that
+// mishandle the Vary header.
+//
+// More specifically, the middleware adds the private directive to the
+// [Cache-Control] header of preflight responses, along with a max-age
+// directive aligned with [MaxAgeInSeconds] if that option is used.
+// Moreover, if the value of the [Access-Control-Allow-Origin] header
+// depends on the request's origin (i.e. if more than one origin is allowed),
+// the middleware also adds the private directive to the Cache-Control header
+// of other responses.
+//
+// The middleware merges those directives into the Cache-Control header
+// that the wrapped handler sets: it drops the directives (e.g. public and
+// s-maxage) that would allow shared caches to store the response, but
+// otherwise lets the handler's directives take precedence.
+//
+// [Access-Control-Allow-Origin]: https://fetch.spec.whatwg.org/#http-access-control-allow-origin
+// [Cache-Control]: https://www.rfc-editor.org/rfc/rfc9111#name-cache-control
+func PreventSharedCaching() Option {
+	return internal.PreventSharedCaching()
+}
+
+// RespondToOptionsRequests configures a CORS middleware to respond,
+// on behalf of the wrapped handler, to OPTIONS requests that are not
+// CORS-preflight requests. The middleware responds with a
+// [204 No Content] status and an [Allow] header that lists the
+// [CORS-safelisted methods], the methods allowed by [WithMethods],
+// and OPTIONS itself; the wrapped handler never sees such requests.
+// CORS-preflight requests are unaffected.
+//
+// By default, the Allow header is derived solely from the CORS
+// configuration; use option [WithRouteMethods] for deriving it from
+// the methods that the requested route actually supports.
+//
+// Using this option in conjunction with option [WithAnyMethod] or option
+// [PreflightPassthrough] results in a failure to build the corresponding
+// middleware.
+//
+// [204 No Content]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Status/204
+// [Allow]: https://www.rfc-editor.org/rfc/rfc9110#name-allow
+// [CORS-safelisted methods]: https://fetch.spec.whatwg.org/#cors-safelisted-method
+func RespondToOptionsRequests() Option {
+	return internal.RespondToOptionsRequests()
+}
+
+// WithRouteMethods configures a CORS middleware that uses option
+// [RespondToOptionsRequests] to derive the Allow header in its responses to
+// non-preflight OPTIONS requests from the methods that methods (typically
+// an integration with your router) reports for the requested route.
+// The Allow header then lists those methods and OPTIONS itself, regardless
+// of the methods allowed for CORS. If methods reports no valid method
+// names for some request (e.g. because it doesn't know the requested
+// route), the middleware falls back to the Allow header that it derives
+// from the CORS configuration.
+//
+// Specifying a nil function, or using this option without option
+// [RespondToOptionsRequests], results in a failure to build
+// the corresponding middleware.
+func WithRouteMethods(methods func(*http.Request) []string) Option {
+	return internal.WithRouteMethods(methods)
+}
@@ -305,3 +305,73 @@ func Test_AllowAccess_From_Any_Origin_With_Any_Method_And_Headers(t *testing.T)
 	}
 	process(t, cors(dummyHandler), cases)
 }
+
+func Test_AllowAccess_From_Any_Origin_With_Any_Method_And_Headers_And_Expose_All_Headers_Without_Wildcards(t *testing.T) {
+	const (
+		dummyVaryValue  = "whatever"
+		dummyStatusCode = 299
+	)
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// remarkable Vary header value to make sure
+		// it isn't suppressed by the middleware
+		w.Header().Add(headerVary, dummyVaryValue)
+		w.Header().Set("X-Foo", "foo")
+		w.WriteHeader(dummyStatusCode)
+	})
+	const dummyValidOrigin = "https://example.com"
+	cors, err := fcors.AllowAccess(
+		fcors.FromAnyOrigin(),
+		fcors.WithAnyMethod(),
+		fcors.WithAnyRequestHeaders(),
+		fcors.ExposeAllResponseHeaders(),
+		fcors.AssumeNoWildcardSupport(),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []TestCase{
+		{
+			name:      "CORS GET request from a valid and allowed origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{dummyValidOrigin},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{wildcard},
+				headerACEH: []string{"x-foo"},
+				headerVary: []string{dummyVaryValue},
+				"X-Foo":    []string{"foo"},
+			},
+		}, {
+			name:      "CORS preflight request with PUT from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{dummyValidOrigin},
+				headerACRM:   []string{http.MethodPut},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{wildcard},
+				headerACAM: []string{http.MethodPut},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with GET with non-safelisted header names from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{dummyValidOrigin},
+				headerACRM:   []string{http.MethodGet},
+				headerACRH:   []string{"foo,bar,authorization"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{wildcard},
+				headerACAH: []string{"foo,bar,authorization"},
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
@@ -1,9 +1,14 @@
 package fcors_test
 
 import (
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/jub0bs/fcors"
 	"github.com/jub0bs/fcors/risky"
@@ -1484,3 +1489,1135 @@ func Test_No_Regression_Of_GHSA_v84h_653v_4pq9(t *testing.T) {
 	}
 	process(t, cors(dummyHandler), cases)
 }
+
+func Test_AllowAccess_From_IP_Prefixes(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins(
+			"http://192.168.0.0/16:*",
+			"http://[fd00::]/8:9090",
+		),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []TestCase{
+		{
+			name:      "CORS GET request from an IPv4 origin within the prefix",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"http://192.168.1.42:3000"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"http://192.168.1.42:3000"},
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from an IPv4 origin outside the prefix",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"http://192.169.1.42:3000"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from an IPv4 origin within the prefix but with https",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://192.168.1.42"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from an IPv6 origin within the prefix",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"http://[fd12:3456::1]:9090"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"http://[fd12:3456::1]:9090"},
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from an IPv6 origin within the prefix but with another port",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"http://[fd12:3456::1]:9091"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from an IPv6 origin in uncompressed form",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"http://[fd12:3456:0:0:0:0:0:1]:9090"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS preflight request with GET from an IPv4 origin within the prefix",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"http://192.168.255.255"},
+				headerACRM:   []string{http.MethodGet},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"http://192.168.255.255"},
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_From_Single_Origin_Pattern_With_Arbitrary_Ports(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins("http://localhost:*"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []TestCase{
+		{
+			name:      "CORS GET request from a valid and allowed origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"http://localhost:9090"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"http://localhost:9090"},
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from a valid but disallowed origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"http://example.com:9090"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_From_Multiple_Origins_Except_Some(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins("https://*.example.com"),
+		fcors.ExceptOrigins(
+			"https://uploads.example.com",
+			"https://*.sandbox.example.com",
+		),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	const allowedOrigin = "https://foo.example.com"
+	cases := []TestCase{
+		{
+			name:      "CORS GET request from an allowed origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from an excluded origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://uploads.example.com"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from an origin encompassed by an excluded pattern",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://foo.sandbox.example.com"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from the base origin of an excluded pattern",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://sandbox.example.com"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"https://sandbox.example.com"},
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS preflight request with GET from an excluded origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://uploads.example.com"},
+				headerACRM:   []string{http.MethodGet},
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_From_Subdomains_With_Replaced_Public_Suffix_List(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	// According to this list, github.io is not a public suffix.
+	fsys := fstest.MapFS{
+		"psl.dat": {Data: []byte("io\n")},
+	}
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins("https://*.github.io"),
+		risky.ReplacePublicSuffixList(fsys, "psl.dat"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	const allowedOrigin = "https://jub0bs.github.io"
+	cases := []TestCase{
+		{
+			name:      "CORS GET request from an allowed origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from a disallowed origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://github.io"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_From_Subdomains_With_Arbitrary_Ports(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins("http://*.dev.example.com:*"),
+		risky.DangerouslyTolerateSubdomainsWithArbitraryPorts(),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []TestCase{
+		{
+			name:      "CORS GET request from a subdomain without port",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"http://alice.dev.example.com"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"http://alice.dev.example.com"},
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from a subdomain with some port",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"http://bob.dev.example.com:31337"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"http://bob.dev.example.com:31337"},
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from the base domain",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"http://dev.example.com:8080"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from a subdomain with a different scheme",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://alice.dev.example.com:8080"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_From_Loopback_Origins(t *testing.T) {
+	t.Setenv("FCORS_DEV", "true")
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	cors, err := fcors.AllowAccess(
+		fcors.FromLoopbackOrigins(),
+		fcors.FromOrigins("https://example.com"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	var cases []TestCase
+	for _, o := range []string{
+		"http://localhost",
+		"https://localhost:8443",
+		"http://127.0.0.1:9090",
+		"https://127.1.2.3:8443",
+		"http://[::1]:3000",
+		"https://[::1]",
+		"https://example.com",
+	} {
+		c := TestCase{
+			name:      "CORS GET request from allowed origin " + o,
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{o},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{o},
+				headerVary: []string{headerOrigin},
+			},
+		}
+		cases = append(cases, c)
+	}
+	for _, o := range []string{
+		"http://localhost.example.com",
+		"http://foo.localhost",
+		"http://128.0.0.1",
+		"http://[::2]",
+		"http://example.com",
+	} {
+		c := TestCase{
+			name:      "CORS GET request from disallowed origin " + o,
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{o},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		}
+		cases = append(cases, c)
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_From_Loopback_Origins_And_Any_Origin(t *testing.T) {
+	t.Setenv("FCORS_DEV", "true")
+	_, err := fcors.AllowAccess(
+		fcors.FromLoopbackOrigins(),
+		fcors.FromAnyOrigin(),
+	)
+	const want = "fcors: incompatible options FromLoopbackOrigins and FromAnyOrigin"
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v; want error with message %q", err, want)
+	}
+}
+
+func Test_AllowAccess_From_Sites(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	cors, err := fcors.AllowAccess(
+		fcors.FromSites("example.com", "bücher.example"),
+		fcors.FromOrigins("http://localhost:8080"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	var cases []TestCase
+	for _, o := range []string{
+		"https://example.com",
+		"https://foo.example.com",
+		"https://foo.bar.example.com",
+		"https://xn--bcher-kva.example",
+		"https://www.xn--bcher-kva.example",
+		"http://localhost:8080",
+	} {
+		c := TestCase{
+			name:      "CORS GET request from allowed origin " + o,
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{o},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{o},
+				headerVary: []string{headerOrigin},
+			},
+		}
+		cases = append(cases, c)
+	}
+	for _, o := range []string{
+		"http://example.com",
+		"http://foo.example.com",
+		"https://example.com:8443",
+		"https://fooexample.com",
+		"https://example.org",
+	} {
+		c := TestCase{
+			name:      "CORS GET request from disallowed origin " + o,
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{o},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		}
+		cases = append(cases, c)
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_From_Time_Bounded_Origins(t *testing.T) {
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {})
+	var (
+		pilotStart = time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+		pilotEnd   = time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+		now        time.Time
+	)
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins("https://example.com"),
+		fcors.FromOriginsUntil(pilotEnd, "https://partner-a.example"),
+		fcors.FromOriginsBetween(pilotStart, pilotEnd, "https://*.partner-b.example"),
+		fcors.WithClock(func() time.Time { return now }),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []struct {
+		now     time.Time
+		origin  string
+		allowed bool
+	}{
+		{pilotStart.Add(-time.Second), "https://example.com", true},
+		{pilotStart.Add(-time.Second), "https://partner-a.example", true},
+		{pilotStart.Add(-time.Second), "https://app.partner-b.example", false},
+		{pilotStart, "https://partner-a.example", true},
+		{pilotStart, "https://app.partner-b.example", true},
+		{pilotEnd.Add(-time.Second), "https://partner-a.example", true},
+		{pilotEnd.Add(-time.Second), "https://app.partner-b.example", true},
+		{pilotEnd, "https://example.com", true},
+		{pilotEnd, "https://partner-a.example", false},
+		{pilotEnd, "https://app.partner-b.example", false},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			now = c.now
+			req := newRequest(http.MethodGet, http.Header{
+				headerOrigin: []string{c.origin},
+			})
+			rec := httptest.NewRecorder()
+			cors(dummyHandler).ServeHTTP(rec, req)
+			acao := rec.Result().Header.Get(headerACAO)
+			if allowed := acao == c.origin; allowed != c.allowed {
+				t.Errorf("got ACAO %q; want access allowed: %t", acao, c.allowed)
+			}
+		}
+		t.Run(c.origin+" at "+c.now.Format(time.RFC3339), f)
+	}
+}
+
+func Test_AllowAccess_From_Labeled_Origins_With_Preflight_And_Decision(t *testing.T) {
+	const headerOriginLabel = "X-Origin-Label"
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if label, found := fcors.OriginLabel(r.Context()); found {
+			w.Header().Set(headerOriginLabel, label)
+		}
+	})
+	const (
+		labeledOrigin   = "https://app.acme.example"
+		unlabeledOrigin = "https://example.com"
+	)
+	cases := []struct {
+		desc      string
+		origin    string
+		wantLabel string
+	}{
+		{"preflight from labeled origin", labeledOrigin, "partner:acme"},
+		{"preflight from unlabeled origin", unlabeledOrigin, ""},
+	}
+	for _, passthrough := range []bool{false, true} {
+		opts := []fcors.OptionAnon{
+			fcors.FromOrigins(unlabeledOrigin),
+			fcors.FromLabeledOrigins("partner:acme", "https://*.acme.example"),
+		}
+		if passthrough {
+			opts = append(opts, fcors.PreflightPassthrough())
+		}
+		cors, err := fcors.AllowAccess(opts[0], opts[1:]...)
+		if err != nil {
+			t.Errorf("got error with message %q; want nil error", err.Error())
+			return
+		}
+		handler := cors(dummyHandler)
+		for _, c := range cases {
+			f := func(t *testing.T) {
+				req := newRequest(http.MethodOptions, http.Header{
+					headerOrigin: []string{c.origin},
+					headerACRM:   []string{http.MethodGet},
+				})
+				ctx, d := fcors.RecordDecision(req.Context())
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req.WithContext(ctx))
+				if d.OriginLabel != c.wantLabel {
+					t.Errorf("got label %q in decision; want %q", d.OriginLabel, c.wantLabel)
+				}
+				want := c.wantLabel
+				if !passthrough {
+					want = "" // the handler isn't invoked
+				}
+				if got := rec.Header().Get(headerOriginLabel); got != want {
+					t.Errorf("got label %q in handler; want %q", got, want)
+				}
+			}
+			t.Run(fmt.Sprintf("%s (passthrough: %t)", c.desc, passthrough), f)
+		}
+	}
+}
+
+func TestValidityWindows(t *testing.T) {
+	var (
+		pilotStart = time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+		pilotEnd   = time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+	)
+	windows, err := fcors.ValidityWindows(
+		fcors.FromOrigins("https://example.com"),
+		fcors.FromOriginsUntil(pilotEnd, "https://partner-a.example", "https://partner-a.example:8443"),
+		fcors.FromOriginsBetween(pilotStart, pilotEnd, "https://*.partner-b.example"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	want := []fcors.ValidityWindow{
+		{
+			Patterns: []string{"https://partner-a.example", "https://partner-a.example:8443"},
+			NotAfter: pilotEnd,
+		}, {
+			Patterns:  []string{"https://*.partner-b.example"},
+			NotBefore: pilotStart,
+			NotAfter:  pilotEnd,
+		},
+	}
+	if !slices.EqualFunc(windows, want, func(a, b fcors.ValidityWindow) bool {
+		return slices.Equal(a.Patterns, b.Patterns) &&
+			a.NotBefore.Equal(b.NotBefore) &&
+			a.NotAfter.Equal(b.NotAfter)
+	}) {
+		t.Errorf("got %v; want %v", windows, want)
+	}
+	now := pilotEnd.Add(-time.Hour)
+	if got := windows[0].Remaining(now); got != time.Hour {
+		t.Errorf("got remaining duration %v; want %v", got, time.Hour)
+	}
+	_, err = fcors.ValidityWindowsWithCredentials(
+		fcors.FromOriginsBetween(pilotEnd, pilotStart, "https://partner-a.example"),
+	)
+	if err == nil {
+		t.Error("got nil error; want non-nil error")
+	}
+}
+
+func Test_AllowAccess_From_Labeled_Origins(t *testing.T) {
+	const (
+		dummyStatusCode   = 299
+		headerOriginLabel = "X-Origin-Label"
+	)
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if label, found := fcors.OriginLabel(r.Context()); found {
+			w.Header().Set(headerOriginLabel, label)
+		}
+		w.WriteHeader(dummyStatusCode)
+	})
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins("https://example.com"),
+		fcors.FromLabeledOrigins("partner:acme", "https://*.acme.example"),
+		fcors.FromLabeledOrigins("internal", "https://*.example.com", "https://admin.acme.example"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []TestCase{
+		{
+			name:      "CORS GET request from an allowed unlabeled origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://example.com"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"https://example.com"},
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from an allowed labeled origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://foo.example.com"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO:        []string{"https://foo.example.com"},
+				headerVary:        []string{headerOrigin},
+				headerOriginLabel: []string{"internal"},
+			},
+		}, {
+			name:      "CORS GET request from an origin with multiple labels",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://admin.acme.example"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO:        []string{"https://admin.acme.example"},
+				headerVary:        []string{headerOrigin},
+				headerOriginLabel: []string{"partner:acme"},
+			},
+		}, {
+			name:      "CORS GET request from a disallowed origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://example.org"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_With_Bundles(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	platform := fcors.Bundle(
+		"platform",
+		fcors.FromOrigins("https://monitoring.example.com"),
+		fcors.WithRequestHeaders("X-Request-Id"),
+		fcors.ExposeResponseHeaders("X-Trace-Id"),
+		fcors.MaxAgeInSeconds(600),
+	)
+	cors, err := fcors.AllowAccess(
+		platform,
+		fcors.FromOrigins("https://app.example.com"),
+		fcors.WithMethods(http.MethodPut),
+		fcors.WithRequestHeaders("Content-Type"),
+		fcors.ExposeResponseHeaders("X-Foo"),
+		fcors.MaxAgeInSeconds(600),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []TestCase{
+		{
+			name:      "CORS GET request from an origin allowed by the bundle",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://monitoring.example.com"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"https://monitoring.example.com"},
+				headerACEH: []string{"x-foo,x-trace-id"},
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS preflight request from an origin allowed outside the bundle",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://app.example.com"},
+				headerACRM:   []string{http.MethodPut},
+				headerACRH:   []string{"content-type,x-request-id"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"https://app.example.com"},
+				headerACAM: []string{http.MethodPut},
+				headerACAH: []string{"content-type,x-request-id"},
+				headerACMA: []string{"600"},
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_With_Enforced_Request_Headers(t *testing.T) {
+	const allowedOrigin = "https://example.com"
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins(allowedOrigin),
+		fcors.WithRequestHeaders("Content-Type", "X-Foo"),
+		fcors.EnforceRequestHeaders(),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []TestCase{
+		{
+			name:      "CORS preflight request with allowed headers",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+				headerACRH:   []string{"content-type,x-foo"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAH: []string{"content-type,x-foo"},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with a subset of allowed headers",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+				headerACRH:   []string{"x-foo"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAH: []string{"content-type,x-foo"},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with a disallowed header",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+				headerACRH:   []string{"authorization,x-foo"},
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with a malformed ACRH header",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+				headerACRH:   []string{"x-foo,,content-type"},
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_With_Enforced_Request_Headers_Records_Failures(t *testing.T) {
+	const allowedOrigin = "https://example.com"
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins(allowedOrigin),
+		fcors.WithMethods(http.MethodPut),
+		fcors.WithRequestHeaders("Content-Type"),
+		fcors.EnforceRequestHeaders(),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	handler := cors(dummyHandler)
+	cases := []struct {
+		desc       string
+		reqHeaders http.Header
+		wantStatus int
+		want       fcors.PreflightFailure
+	}{
+		{
+			desc: "allowed preflight",
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+				headerACRH:   []string{"content-type"},
+			},
+			wantStatus: defaultPreflightSuccessStatus,
+		}, {
+			desc: "disallowed origin",
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://attacker.com"},
+				headerACRM:   []string{http.MethodPut},
+				headerACRH:   []string{"content-type"},
+			},
+			wantStatus: http.StatusForbidden,
+			want:       fcors.OriginNotAllowed,
+		}, {
+			desc: "disallowed method (left to the browser)",
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodDelete},
+			},
+			wantStatus: defaultPreflightSuccessStatus,
+		}, {
+			desc: "disallowed request headers",
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+				headerACRH:   []string{"content-type,x-bar"},
+			},
+			wantStatus: http.StatusForbidden,
+			want:       fcors.RequestHeadersNotAllowed,
+		}, {
+			desc: "disallowed private-network access",
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+				headerACRPN:  []string{headerValueTrue},
+			},
+			wantStatus: defaultPreflightSuccessStatus,
+			want:       fcors.PrivateNetworkAccessNotAllowed,
+		},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			req := newRequest(http.MethodOptions, c.reqHeaders)
+			ctx, d := fcors.RecordDecision(req.Context())
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req.WithContext(ctx))
+			if rec.Code != c.wantStatus {
+				t.Errorf("got status %d; want %d", rec.Code, c.wantStatus)
+			}
+			if d.PreflightFailure != c.want {
+				t.Errorf("got preflight failure %q; want %q", d.PreflightFailure, c.want)
+			}
+		}
+		t.Run(c.desc, f)
+	}
+}
+
+func Test_AllowAccess_With_Enforced_Request_Headers_And_PreflightPassthrough(t *testing.T) {
+	const (
+		dummyStatusCode = 299
+		headerHandled   = "X-Handled"
+	)
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(headerHandled, headerValueTrue)
+		w.WriteHeader(dummyStatusCode)
+	})
+	const allowedOrigin = "https://example.com"
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins(allowedOrigin),
+		fcors.WithMethods(http.MethodPut),
+		fcors.WithRequestHeaders("Content-Type"),
+		fcors.EnforceRequestHeaders(),
+		fcors.PreflightPassthrough(),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	handler := cors(dummyHandler)
+	cases := []struct {
+		desc        string
+		reqHeaders  http.Header
+		wantStatus  int
+		wantHandled bool
+		want        fcors.PreflightFailure
+	}{
+		{
+			desc: "allowed preflight",
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+				headerACRH:   []string{"content-type"},
+			},
+			wantStatus:  dummyStatusCode,
+			wantHandled: true,
+		}, {
+			desc: "disallowed origin",
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://attacker.com"},
+				headerACRM:   []string{http.MethodPut},
+			},
+			wantStatus: http.StatusForbidden,
+			want:       fcors.OriginNotAllowed,
+		}, {
+			desc: "disallowed request headers",
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+				headerACRH:   []string{"content-type,x-bar"},
+			},
+			wantStatus: http.StatusForbidden,
+			want:       fcors.RequestHeadersNotAllowed,
+		}, {
+			desc: "disallowed private-network access",
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+				headerACRPN:  []string{headerValueTrue},
+			},
+			wantStatus:  dummyStatusCode,
+			wantHandled: true,
+			want:        fcors.PrivateNetworkAccessNotAllowed,
+		},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			req := newRequest(http.MethodOptions, c.reqHeaders)
+			ctx, d := fcors.RecordDecision(req.Context())
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req.WithContext(ctx))
+			if rec.Code != c.wantStatus {
+				t.Errorf("got status %d; want %d", rec.Code, c.wantStatus)
+			}
+			if handled := rec.Header().Get(headerHandled) != ""; handled != c.wantHandled {
+				t.Errorf("got handled %t; want %t", handled, c.wantHandled)
+			}
+			if d.PreflightFailure != c.want {
+				t.Errorf("got preflight failure %q; want %q", d.PreflightFailure, c.want)
+			}
+		}
+		t.Run(c.desc, f)
+	}
+}
+
+func Test_AllowAccess_With_Request_Header_Prefixes(t *testing.T) {
+	const allowedOrigin = "https://example.com"
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins(allowedOrigin),
+		fcors.WithMethods(http.MethodPut),
+		fcors.WithRequestHeaders("Content-Type", "X-Amz-*", "x-datadog-*"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []TestCase{
+		{
+			name:      "CORS preflight request with headers matching prefixes",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+				headerACRH:   []string{"content-type,x-amz-content-sha256,x-amz-date,x-datadog-trace-id"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAM: []string{http.MethodPut},
+				headerACAH: []string{"content-type,x-amz-content-sha256,x-amz-date,x-datadog-trace-id"},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with a header that is a mere prefix",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+				headerACRH:   []string{"x-amz-"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAM: []string{http.MethodPut},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with a disallowed header",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+				headerACRH:   []string{"x-amz-date,x-goog-date"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAM: []string{http.MethodPut},
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_With_Any_Request_Headers_Except(t *testing.T) {
+	const allowedOrigin = "https://example.com"
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins(allowedOrigin),
+		fcors.WithAnyRequestHeadersExcept("X-Internal-Auth"),
+		fcors.EnforceRequestHeaders(),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []TestCase{
+		{
+			name:      "CORS preflight request with allowed headers",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+				headerACRH:   []string{"authorization,x-foo"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAH: []string{"authorization,x-foo"},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with a denied header",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+				headerACRH:   []string{"x-internal-auth"},
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_With_Request_Headers_For_Method(t *testing.T) {
+	const allowedOrigin = "https://example.com"
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins(allowedOrigin),
+		fcors.WithMethods(http.MethodPut, http.MethodDelete),
+		fcors.WithRequestHeaders("Content-Type"),
+		fcors.WithRequestHeadersForMethod(http.MethodPut, "X-Upload-Id", "Content-Range"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []TestCase{
+		{
+			name:      "CORS preflight request with PUT and headers allowed for PUT",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+				headerACRH:   []string{"content-range,content-type,x-upload-id"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAM: []string{"DELETE,PUT"},
+				headerACAH: []string{"content-range,content-type,x-upload-id"},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with PUT and a disallowed header",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+				headerACRH:   []string{"x-foo,x-upload-id"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAM: []string{"DELETE,PUT"},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with DELETE and headers allowed only for PUT",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodDelete},
+				headerACRH:   []string{"x-upload-id"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAM: []string{"DELETE,PUT"},
+				headerACAH: []string{"content-type"},
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
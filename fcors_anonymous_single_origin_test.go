@@ -2,6 +2,7 @@ package fcors_test
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -2175,3 +2176,304 @@ func Test_AllowAccess_From_Localhost(t *testing.T) {
 	}
 	process(t, cors(dummyHandler), cases)
 }
+
+func Test_AllowAccess_From_Single_Origin_In_Unicode_Form(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins("https://bücher.example"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	const (
+		allowedOrigin    = "https://xn--bcher-kva.example"
+		disallowedOrigin = "https://bucher.example"
+	)
+	cases := []TestCase{
+		{
+			name:      "CORS GET request from a valid and allowed origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+			},
+		}, {
+			name:      "CORS preflight request with GET from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with GET from a valid but disallowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{disallowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_From_Single_Origin_With_PreflightPassthrough(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	const allowedOrigin = "https://example.com"
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins(allowedOrigin),
+		fcors.WithMethods(http.MethodPut),
+		fcors.PreflightPassthrough(),
+		risky.PrivateNetworkAccess(),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	const disallowedOrigin = "https://foo.example.com"
+	cases := []TestCase{
+		{
+			name:      "CORS preflight request with PUT from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAM: []string{http.MethodPut},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with PUT with ACRPN from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+				headerACRPN:  []string{headerValueTrue},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO:  []string{allowedOrigin},
+				headerACAM:  []string{http.MethodPut},
+				headerACAPN: []string{headerValueTrue},
+				headerVary:  []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with disallowed DELETE from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodDelete},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAM: []string{http.MethodPut},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with PUT from a valid but disallowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{disallowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_From_Single_Origin_With_PreflightPassthrough_And_PreflightSuccessStatus(t *testing.T) {
+	const (
+		customPreflightSuccessStatus = 279
+		dummyStatusCode              = 299
+	)
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The handler writes a status only if asked to.
+		if r.URL.Query().Has("explicit") {
+			w.WriteHeader(dummyStatusCode)
+		}
+		if r.URL.Query().Has("body") {
+			w.Write([]byte("Hello, World!"))
+		}
+	})
+	const allowedOrigin = "https://example.com"
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins(allowedOrigin),
+		fcors.WithMethods(http.MethodPut),
+		fcors.PreflightPassthrough(),
+		fcors.PreflightSuccessStatus(customPreflightSuccessStatus),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	handler := cors(dummyHandler)
+	cases := []struct {
+		desc       string
+		query      string
+		wantStatus int
+	}{
+		{"handler writes no status", "", customPreflightSuccessStatus},
+		{"handler writes a body only", "?body", customPreflightSuccessStatus},
+		{"handler writes a status", "?explicit", dummyStatusCode},
+		{"handler writes a status and a body", "?explicit&body", dummyStatusCode},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "https://example.com/"+c.query, nil)
+			req.Header.Set(headerOrigin, allowedOrigin)
+			req.Header.Set(headerACRM, http.MethodPut)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != c.wantStatus {
+				t.Errorf("got status %d; want %d", rec.Code, c.wantStatus)
+			}
+			if got := rec.Header().Get(headerACAO); got != allowedOrigin {
+				t.Errorf("got %s %q; want %q", headerACAO, got, allowedOrigin)
+			}
+		}
+		t.Run(c.desc, f)
+	}
+}
+
+func Test_AllowAccess_From_Single_Origin_Respond_To_Options_Requests(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	const allowedOrigin = "https://example.com"
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins(allowedOrigin),
+		fcors.WithMethods(http.MethodPut, http.MethodDelete),
+		fcors.RespondToOptionsRequests(),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	const allowValue = "DELETE, GET, HEAD, OPTIONS, POST, PUT"
+	cases := []TestCase{
+		{
+			name:           "non-CORS GET request",
+			reqMethod:      http.MethodGet,
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+			},
+		}, {
+			name:           "non-CORS OPTIONS request",
+			reqMethod:      http.MethodOptions,
+			expectedStatus: http.StatusNoContent,
+			expectedRespHeaders: http.Header{
+				headerACAO:  []string{allowedOrigin},
+				headerAllow: []string{allowValue},
+				headerVary:  []string{varyPreflightValue},
+			},
+		}, {
+			name:      "non-preflight CORS OPTIONS request from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedRespHeaders: http.Header{
+				headerACAO:  []string{allowedOrigin},
+				headerAllow: []string{allowValue},
+				headerVary:  []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with PUT from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodPut},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAM: []string{"DELETE,PUT"},
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccess_From_Single_Origin_Respond_To_Options_Requests_With_Route_Methods(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	// This function stands for a router whose /items route supports
+	// GET and PATCH (which isn't allowed for CORS) and whose /bogus route
+	// reports no valid method.
+	routeMethods := func(r *http.Request) []string {
+		switch r.URL.Path {
+		case "/items":
+			return []string{http.MethodPatch, http.MethodGet}
+		case "/bogus":
+			return []string{"not a method"}
+		default:
+			return nil
+		}
+	}
+	const allowedOrigin = "https://example.com"
+	cors, err := fcors.AllowAccess(
+		fcors.FromOrigins(allowedOrigin),
+		fcors.WithMethods(http.MethodPut),
+		fcors.RespondToOptionsRequests(),
+		fcors.WithRouteMethods(routeMethods),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	handler := cors(dummyHandler)
+	cases := []struct {
+		path      string
+		wantAllow string
+	}{
+		{"/items", "GET, OPTIONS, PATCH"},
+		{"/bogus", "GET, HEAD, OPTIONS, POST, PUT"},
+		{"/unknown", "GET, HEAD, OPTIONS, POST, PUT"},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "https://example.com"+c.path, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusNoContent {
+				t.Errorf("got status %d; want %d", rec.Code, http.StatusNoContent)
+			}
+			if got := rec.Header().Get(headerAllow); got != c.wantAllow {
+				t.Errorf("got %s %q; want %q", headerAllow, got, c.wantAllow)
+			}
+		}
+		t.Run(c.path, f)
+	}
+}
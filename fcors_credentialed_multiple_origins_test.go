@@ -2,10 +2,13 @@ package fcors_test
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/jub0bs/fcors"
+	"github.com/jub0bs/fcors/risky"
 )
 
 func Test_AllowAccessWithCredentials_From_Multiple_Origins(t *testing.T) {
@@ -274,3 +277,534 @@ func Test_AllowAccessWithCredentials_From_Multiple_Origins(t *testing.T) {
 	}
 	process(t, cors(dummyHandler), cases)
 }
+
+func Test_AllowAccessWithCredentials_From_Same_Site_Origins(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	// Note: the requests sent in this test target host example.com.
+	cors, err := fcors.AllowAccessWithCredentials(
+		fcors.FromSameSiteOrigins(),
+		fcors.FromOrigins("https://example.org"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	var cases []TestCase
+	for _, o := range []string{
+		"https://example.com",
+		"https://www.example.com",
+		"https://foo.bar.example.com:8443",
+		"https://example.org",
+	} {
+		c := TestCase{
+			name:      "CORS GET request from allowed origin " + o,
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{o},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{o},
+				headerACAC: []string{"true"},
+				headerVary: []string{headerOrigin},
+			},
+		}
+		cases = append(cases, c)
+	}
+	for _, o := range []string{
+		"http://www.example.com",
+		"https://fooexample.com",
+		"https://www.example.org",
+	} {
+		c := TestCase{
+			name:      "CORS GET request from disallowed origin " + o,
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{o},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		}
+		cases = append(cases, c)
+	}
+	cases = append(cases, TestCase{
+		name:      "CORS GET request with untrusted X-Forwarded-Host",
+		reqMethod: http.MethodGet,
+		reqHeaders: http.Header{
+			headerOrigin:       []string{"https://attacker.example"},
+			"X-Forwarded-Host": []string{"attacker.example"},
+		},
+		expectedStatus: dummyStatusCode,
+		expectedRespHeaders: http.Header{
+			headerVary: []string{headerOrigin},
+		},
+	})
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccessWithCredentials_From_Same_Site_Origins_Behind_Proxy(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	cors, err := fcors.AllowAccessWithCredentials(
+		fcors.FromSameSiteOrigins(),
+		risky.TrustXForwardedHost(),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	const (
+		varyValue          = headerOrigin + ", " + headerXFH
+		varyPreflightValue = varyPreflightValue + ", " + headerXFH
+	)
+	cases := []TestCase{
+		{
+			name:      "CORS GET request from origin same site with X-Forwarded-Host",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://www.brand.co.uk"},
+				headerXFH:    []string{"API.brand.co.uk:443, proxy.internal"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"https://www.brand.co.uk"},
+				headerACAC: []string{"true"},
+				headerVary: []string{varyValue},
+			},
+		}, {
+			name:      "CORS GET request from origin same site with Host only",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://www.example.com"},
+				headerXFH:    []string{"brand.co.uk"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{varyValue},
+			},
+		}, {
+			name:      "CORS GET request with X-Forwarded-Host being a public suffix",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://attacker.co.uk"},
+				headerXFH:    []string{"co.uk"},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{varyValue},
+			},
+		}, {
+			name:      "CORS preflight request from origin same site with X-Forwarded-Host",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://www.brand.co.uk"},
+				headerACRM:   []string{http.MethodGet},
+				headerXFH:    []string{"brand.co.uk"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"https://www.brand.co.uk"},
+				headerACAC: []string{"true"},
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccessWithCredentials_From_Same_Site_Origins_Except_Some(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	// Note: the requests sent in this test target host example.com.
+	cors, err := fcors.AllowAccessWithCredentials(
+		fcors.FromSameSiteOrigins(),
+		fcors.FromOrigins("https://*.example.com"),
+		fcors.ExceptOrigins("https://uploads.example.com"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	const (
+		allowedOrigin  = "https://api.example.com"
+		excludedOrigin = "https://uploads.example.com"
+	)
+	cases := []TestCase{
+		{
+			name:      "CORS GET request from a same-site origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAC: []string{headerValueTrue},
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from an excluded same-site origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{excludedOrigin},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS preflight request with GET from an excluded same-site origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{excludedOrigin},
+				headerACRM:   []string{http.MethodGet},
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccessWithCredentials_From_Same_Site_Origins_Only_Except_Some(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(dummyStatusCode)
+	})
+	// Note: the requests sent in this test target host example.com.
+	cors, err := fcors.AllowAccessWithCredentials(
+		fcors.FromSameSiteOrigins(),
+		fcors.ExceptOrigins("https://uploads.example.com"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	const (
+		allowedOrigin  = "https://api.example.com"
+		excludedOrigin = "https://uploads.example.com"
+	)
+	cases := []TestCase{
+		{
+			name:      "CORS GET request from a same-site origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAC: []string{headerValueTrue},
+				headerVary: []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from an excluded same-site origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{excludedOrigin},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccessWithCredentials_From_Origins_For_Host(t *testing.T) {
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {})
+	cors, err := fcors.AllowAccessWithCredentials(
+		fcors.FromOriginsForHost("api.customer-a.com", "https://app.customer-a.com"),
+		fcors.FromOriginsForHost("API.customer-b.com", "https://*.customer-b.com"),
+		fcors.FromOriginsForHost("::1", "https://app.customer-d.com"),
+		fcors.FromOrigins("https://example.com"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []struct {
+		host    string
+		origin  string
+		allowed bool
+	}{
+		{"api.customer-a.com", "https://app.customer-a.com", true},
+		{"api.customer-a.com:8443", "https://app.customer-a.com", true},
+		{"api.customer-a.com", "https://app.customer-b.com", false},
+		{"api.customer-a.com", "https://example.com", false},
+		{"api.customer-b.com", "https://app.customer-b.com", true},
+		{"api.customer-b.com", "https://app.customer-a.com", false},
+		{"api.customer-c.com", "https://example.com", true},
+		{"api.customer-c.com", "https://app.customer-a.com", false},
+		{"[::1]:8443", "https://app.customer-d.com", true},
+		{"[::1]", "https://example.com", false},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			req := newRequest(http.MethodGet, http.Header{
+				headerOrigin: []string{c.origin},
+			})
+			req.Host = c.host
+			rec := httptest.NewRecorder()
+			cors(dummyHandler).ServeHTTP(rec, req)
+			acao := rec.Result().Header.Get(headerACAO)
+			if allowed := acao == c.origin; allowed != c.allowed {
+				t.Errorf("got ACAO %q; want access allowed: %t", acao, c.allowed)
+			}
+		}
+		t.Run(c.origin+" to "+c.host, f)
+	}
+}
+
+func Test_AllowAccessWithCredentials_From_Origins_For_Host_Behind_Proxy(t *testing.T) {
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {})
+	cors, err := fcors.AllowAccessWithCredentials(
+		fcors.FromOriginsForHost("api.customer-a.com", "https://app.customer-a.com"),
+		fcors.FromOrigins("https://example.com"),
+		risky.TrustXForwardedHost(),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []TestCase{
+		{
+			name:      "CORS GET request to host with a dedicated allowlist",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://app.customer-a.com"},
+				headerXFH:    []string{"api.customer-a.com"},
+			},
+			expectedStatus: http.StatusOK,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"https://app.customer-a.com"},
+				headerACAC: []string{headerValueTrue},
+				headerVary: []string{headerOrigin + ", " + headerXFH},
+			},
+		}, {
+			name:      "CORS preflight request to host with a dedicated allowlist",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{"https://app.customer-a.com"},
+				headerACRM:   []string{http.MethodGet},
+				headerXFH:    []string{"api.customer-a.com"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{"https://app.customer-a.com"},
+				headerACAC: []string{headerValueTrue},
+				headerVary: []string{varyPreflightValue + ", " + headerXFH},
+			},
+		}, {
+			name:           "non-CORS GET request",
+			reqMethod:      http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedRespHeaders: http.Header{
+				headerVary: []string{headerOrigin + ", " + headerXFH},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccessWithCredentials_With_Any_Request_Headers_Except(t *testing.T) {
+	const allowedOrigin = "https://example.com"
+	cors, err := fcors.AllowAccessWithCredentials(
+		fcors.FromOrigins(allowedOrigin),
+		fcors.WithAnyRequestHeadersExcept("X-Internal-Auth", "X-Forwarded-For"),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	cases := []TestCase{
+		{
+			name:      "CORS preflight request with allowed headers",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+				headerACRH:   []string{"authorization,x-foo"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAC: []string{headerValueTrue},
+				headerACAH: []string{"authorization,x-foo"},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with a denied header",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+				headerACRH:   []string{"x-foo,x-internal-auth"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAC: []string{headerValueTrue},
+				headerVary: []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
+
+func Test_AllowAccessWithCredentials_Expose_Response_Headers_Automatically(t *testing.T) {
+	const (
+		allowedOrigin   = "https://example.com"
+		dummyStatusCode = 299
+	)
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Foo", "foo")
+		w.Header().Set("X-Bar", "bar")
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Set-Cookie", "session=deadbeef")
+		if r.URL.Query().Has("flush") {
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				t.Errorf("got flush error %v; want nil error", err)
+			}
+		}
+		if r.URL.Query().Has("silent") {
+			return
+		}
+		w.WriteHeader(dummyStatusCode)
+		// The following header is set too late to be exposed.
+		w.Header().Set("X-Baz", "baz")
+	})
+	cors, err := fcors.AllowAccessWithCredentials(
+		fcors.FromOrigins(allowedOrigin, "https://example.net"),
+		fcors.ExposeResponseHeadersAutomatically(),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	handler := cors(dummyHandler)
+	cases := []struct {
+		desc   string
+		origin string
+		query  string
+		want   []string
+	}{
+		{
+			desc:   "allowed origin",
+			origin: allowedOrigin,
+			want:   []string{"x-bar,x-foo"},
+		}, {
+			desc:   "allowed origin and handler that flushes",
+			origin: allowedOrigin,
+			query:  "?flush",
+			want:   []string{"x-bar,x-foo"},
+		}, {
+			desc:   "allowed origin and handler that writes nothing",
+			origin: allowedOrigin,
+			query:  "?silent",
+			want:   []string{"x-bar,x-foo"},
+		}, {
+			desc:   "disallowed origin",
+			origin: "https://example.org",
+		},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			req := newRequest(http.MethodGet, http.Header{headerOrigin: []string{c.origin}})
+			req.URL.RawQuery = strings.TrimPrefix(c.query, "?")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			got := rec.Result().Header[headerACEH]
+			if !slices.Equal(got, c.want) {
+				t.Errorf("got ACEH %q; want %q", got, c.want)
+			}
+		}
+		t.Run(c.desc, f)
+	}
+}
+
+func Test_AllowAccessWithCredentials_Prevent_Shared_Caching(t *testing.T) {
+	const dummyStatusCode = 299
+	dummyHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(headerCacheControl, "public, max-age=60")
+		w.WriteHeader(dummyStatusCode)
+	})
+	const dummyMaxAge = 30
+	cors, err := fcors.AllowAccessWithCredentials(
+		fcors.FromOrigins("https://*.example.com"),
+		fcors.MaxAgeInSeconds(dummyMaxAge),
+		fcors.PreventSharedCaching(),
+	)
+	if err != nil {
+		t.Errorf("got error with message %q; want nil error", err.Error())
+		return
+	}
+	const (
+		allowedOrigin        = "https://foo.example.com"
+		disallowedBaseOrigin = "https://example.com"
+	)
+	cases := []TestCase{
+		{
+			name:           "non-CORS GET request",
+			reqMethod:      http.MethodGet,
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerCacheControl: []string{"private, max-age=60"},
+				headerVary:         []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS GET request from a valid and allowed origin",
+			reqMethod: http.MethodGet,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+			},
+			expectedStatus: dummyStatusCode,
+			expectedRespHeaders: http.Header{
+				headerACAO:         []string{allowedOrigin},
+				headerACAC:         []string{headerValueTrue},
+				headerCacheControl: []string{"private, max-age=60"},
+				headerVary:         []string{headerOrigin},
+			},
+		}, {
+			name:      "CORS preflight request with GET from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO:         []string{allowedOrigin},
+				headerACAC:         []string{headerValueTrue},
+				headerACMA:         []string{stringFromUint(dummyMaxAge)},
+				headerCacheControl: []string{"private, max-age=30"},
+				headerVary:         []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with GET from a valid but disallowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{disallowedBaseOrigin},
+				headerACRM:   []string{http.MethodGet},
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedRespHeaders: http.Header{
+				headerCacheControl: []string{"private, max-age=30"},
+				headerVary:         []string{varyPreflightValue},
+			},
+		},
+	}
+	process(t, cors(dummyHandler), cases)
+}
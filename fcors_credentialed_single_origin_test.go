@@ -867,6 +867,75 @@ func Test_AllowAccessWithCredentials_From_Single_Origin_With_Any_Method_And_Head
 			expectedRespHeaders: http.Header{
 				headerVary: []string{varyPreflightValue},
 			},
+		}, {
+			name:      "CORS preflight request with non-normalized header names from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+				headerACRH:   []string{"Foo, bar,foo"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAC: []string{headerValueTrue},
+				headerACAH: []string{"foo,bar"},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with a malformed ACRH header from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+				headerACRH:   []string{"foo\r\nSet-Cookie: bar"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAC: []string{headerValueTrue},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with an abnormally long ACRH header from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{http.MethodGet},
+				headerACRH:   []string{strings.Repeat("foo,", 64) + "foo"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAC: []string{headerValueTrue},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with a malformed ACRM header from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{"PUT PATCH"},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAC: []string{headerValueTrue},
+				headerVary: []string{varyPreflightValue},
+			},
+		}, {
+			name:      "CORS preflight request with an abnormally long ACRM header from a valid and allowed origin",
+			reqMethod: http.MethodOptions,
+			reqHeaders: http.Header{
+				headerOrigin: []string{allowedOrigin},
+				headerACRM:   []string{strings.Repeat("PUT", 22)},
+			},
+			expectedStatus: defaultPreflightSuccessStatus,
+			expectedRespHeaders: http.Header{
+				headerACAO: []string{allowedOrigin},
+				headerACAC: []string{headerValueTrue},
+				headerVary: []string{varyPreflightValue},
+			},
 		},
 	}
 	process(t, cors(dummyHandler), cases)
@@ -4,11 +4,23 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/jub0bs/fcors"
 	"github.com/jub0bs/fcors/risky"
 )
 
+// publicSuffixes contains public-suffix lists used in some of the tests below.
+var publicSuffixes = fstest.MapFS{
+	"psl.dat": {
+		Data: []byte("// corporate suffixes\napps.example.corp\n"),
+	},
+	"invalid.dat": {
+		Data: []byte("// corporate suffixes\n*.*.corp\n"),
+	},
+}
+
 // These tests are only meant as a sanity check, not as a license
 // to depend on the precise wording of the various error messages.
 func TestInvalidPoliciesForAllowAccess(t *testing.T) {
@@ -37,6 +49,22 @@ func TestInvalidPoliciesForAllowAccess(t *testing.T) {
 			},
 			errorMsg: `fcors: insecure origin patterns like "http://example.com:6060" ` +
 				`are by default prohibited when Private-Network Access is enabled`,
+		}, {
+			desc:     "specified origin's host mixes scripts",
+			options:  []fcors.OptionAnon{fcors.FromOrigins("https://раypal.com")},
+			errorMsg: `fcors: prohibited mixed-script label "раypal" in host: "https://раypal.com"`,
+		}, {
+			desc:     "specified origin's host mixes scripts in Punycode form",
+			options:  []fcors.OptionAnon{fcors.FromOrigins("https://xn--ypal-43d9g.com")},
+			errorMsg: `fcors: prohibited mixed-script label "раypal" in host: "https://xn--ypal-43d9g.com"`,
+		}, {
+			desc:     "specified origin's host contains an invalid Punycode label",
+			options:  []fcors.OptionAnon{fcors.FromOrigins("http://xn--f")},
+			errorMsg: `fcors: invalid internationalized domain name in host: "http://xn--f"`,
+		}, {
+			desc:     "specified origin's host is an IP prefix in non-canonical form",
+			options:  []fcors.OptionAnon{fcors.FromOrigins("http://192.168.1.0/16")},
+			errorMsg: `fcors: IP prefix not in canonical form: "http://192.168.1.0/16"`,
 		}, {
 			desc:     "specified origin's host is an invalid IP address",
 			options:  []fcors.OptionAnon{fcors.FromOrigins("http://[::1]1:6060")},
@@ -60,19 +88,19 @@ func TestInvalidPoliciesForAllowAccess(t *testing.T) {
 		}, {
 			desc:     "specified origin contains a path",
 			options:  []fcors.OptionAnon{fcors.FromOrigins("https://example.com:6060/foo")},
-			errorMsg: `fcors: invalid origin pattern "https://example.com:6060/foo"`,
+			errorMsg: `fcors: invalid origin pattern "https://example.com:6060/foo": path; did you mean "https://example.com:6060"?`,
 		}, {
 			desc:     "specified origin contains a querystring delimiter",
 			options:  []fcors.OptionAnon{fcors.FromOrigins("https://example.com:6060?")},
-			errorMsg: `fcors: invalid origin pattern "https://example.com:6060?"`,
+			errorMsg: `fcors: invalid origin pattern "https://example.com:6060?": query or fragment; did you mean "https://example.com:6060"?`,
 		}, {
 			desc:     "specified origin contains a querystring",
 			options:  []fcors.OptionAnon{fcors.FromOrigins("https://example.com:6060?foo=bar")},
-			errorMsg: `fcors: invalid origin pattern "https://example.com:6060?foo=bar"`,
+			errorMsg: `fcors: invalid origin pattern "https://example.com:6060?foo=bar": query or fragment; did you mean "https://example.com:6060"?`,
 		}, {
 			desc:     "specified origin contains a fragment",
 			options:  []fcors.OptionAnon{fcors.FromOrigins("https://example.com:6060#index")},
-			errorMsg: `fcors: invalid origin pattern "https://example.com:6060#index"`,
+			errorMsg: `fcors: invalid origin pattern "https://example.com:6060#index": query or fragment; did you mean "https://example.com:6060"?`,
 		}, {
 			desc:     "specified origin contains an invalid port",
 			options:  []fcors.OptionAnon{fcors.FromOrigins("https://example.com:66536")},
@@ -142,19 +170,19 @@ func TestInvalidPoliciesForAllowAccess(t *testing.T) {
 		}, {
 			desc:     "specified base origin contains a path",
 			options:  []fcors.OptionAnon{fcors.FromOrigins("https://*.example.com:6060/foo")},
-			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060/foo"`,
+			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060/foo": path; did you mean "https://*.example.com:6060"?`,
 		}, {
 			desc:     "specified base origin contains a querystring",
 			options:  []fcors.OptionAnon{fcors.FromOrigins("https://*.example.com:6060?foo=bar")},
-			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060?foo=bar"`,
+			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060?foo=bar": query or fragment; did you mean "https://*.example.com:6060"?`,
 		}, {
 			desc:     "specified origin contains a querystring delimiter",
 			options:  []fcors.OptionAnon{fcors.FromOrigins("https://*.example.com:6060?")},
-			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060?"`,
+			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060?": query or fragment; did you mean "https://*.example.com:6060"?`,
 		}, {
 			desc:     "specified base origin contains a fragment",
 			options:  []fcors.OptionAnon{fcors.FromOrigins("https://*.example.com:6060#index")},
-			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060#index"`,
+			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060#index": query or fragment; did you mean "https://*.example.com:6060"?`,
 		}, {
 			desc:     "specified base origin contains an invalid port",
 			options:  []fcors.OptionAnon{fcors.FromOrigins("https://*.example.com:66536")},
@@ -182,9 +210,10 @@ func TestInvalidPoliciesForAllowAccess(t *testing.T) {
 			errorMsg: `fcors: origin patterns like "https://*.github.io" that encompass ` +
 				`subdomains of a public suffix are by default prohibited`,
 		}, {
-			desc:     "missing call to FromOrigins or FromAnyOrigin",
-			options:  []fcors.OptionAnon{fcors.WithAnyMethod()},
-			errorMsg: `fcors: missing call to FromOrigins or FromAnyOrigin in AllowAccess`,
+			desc:    "missing call to an option that specifies allowed origins",
+			options: []fcors.OptionAnon{fcors.WithAnyMethod()},
+			errorMsg: `fcors: missing call to some option that specifies allowed origins ` +
+				`(e.g. FromOrigins or FromAnyOrigin) in AllowAccess`,
 		}, {
 			desc: "empty method name",
 			options: []fcors.OptionAnon{
@@ -462,6 +491,487 @@ func TestInvalidPoliciesForAllowAccess(t *testing.T) {
 				risky.DangerouslyTolerateSubdomainsOfPublicSuffixes(),
 			},
 			errorMsg: `fcors/risky: option DangerouslyTolerateSubdomainsOfPublicSuffixes used multiple times`,
+		}, {
+			desc: "specified base origin's host is an additional public suffix",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://*.apps.example.corp"),
+				fcors.AdditionalPublicSuffixes(publicSuffixes, "psl.dat"),
+			},
+			errorMsg: `fcors: origin patterns like "https://*.apps.example.corp" that encompass ` +
+				`subdomains of a public suffix are by default prohibited`,
+		}, {
+			desc: "public-suffix list cannot be read",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://example.com"),
+				fcors.AdditionalPublicSuffixes(publicSuffixes, "missing.dat"),
+			},
+			errorMsg: `fcors: failed to read public-suffix list "missing.dat": ` +
+				`open missing.dat: file does not exist`,
+		}, {
+			desc: "public-suffix list contains an invalid rule",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://example.com"),
+				fcors.AdditionalPublicSuffixes(publicSuffixes, "invalid.dat"),
+			},
+			errorMsg: `fcors: invalid rule "*.*.corp" at line 2 of public-suffix list "invalid.dat"`,
+		}, {
+			desc: "option AdditionalPublicSuffixes used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://example.com"),
+				fcors.AdditionalPublicSuffixes(publicSuffixes, "psl.dat"),
+				fcors.AdditionalPublicSuffixes(publicSuffixes, "psl.dat"),
+			},
+			errorMsg: `fcors: option AdditionalPublicSuffixes used multiple times`,
+		}, {
+			desc: "option ReplacePublicSuffixList used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://example.com"),
+				risky.ReplacePublicSuffixList(publicSuffixes, "psl.dat"),
+				risky.ReplacePublicSuffixList(publicSuffixes, "psl.dat"),
+			},
+			errorMsg: `fcors/risky: option ReplacePublicSuffixList used multiple times`,
+		}, {
+			desc: "conjunct use of options AdditionalPublicSuffixes and ReplacePublicSuffixList",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://example.com"),
+				fcors.AdditionalPublicSuffixes(publicSuffixes, "psl.dat"),
+				risky.ReplacePublicSuffixList(publicSuffixes, "psl.dat"),
+			},
+			errorMsg: `fcors: incompatible options AdditionalPublicSuffixes and ReplacePublicSuffixList`,
+		}, {
+			desc: "specified origin pattern has both arbitrary subdomains and arbitrary ports",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://*.example.com:*"),
+			},
+			errorMsg: `fcors: origin patterns like "https://*.example.com:*" that specify ` +
+				`both arbitrary subdomains and arbitrary ports are by default prohibited`,
+		}, {
+			desc: "specified origin pattern has both arbitrary subdomains of a public suffix and arbitrary ports",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://*.github.io:*"),
+				risky.DangerouslyTolerateSubdomainsWithArbitraryPorts(),
+				risky.DangerouslyTolerateSubdomainsOfPublicSuffixes(),
+			},
+			errorMsg: `fcors: origin pattern "https://*.github.io:*" that specifies ` +
+				`both arbitrary subdomains of a public suffix and arbitrary ports is prohibited`,
+		}, {
+			desc: "option DangerouslyTolerateSubdomainsWithArbitraryPorts used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://*.example.com:*"),
+				risky.DangerouslyTolerateSubdomainsWithArbitraryPorts(),
+				risky.DangerouslyTolerateSubdomainsWithArbitraryPorts(),
+			},
+			errorMsg: `fcors/risky: option DangerouslyTolerateSubdomainsWithArbitraryPorts used multiple times`,
+		}, {
+			desc: "specified site is a public suffix",
+			options: []fcors.OptionAnon{
+				fcors.FromSites("example.com", "co.uk"),
+			},
+			errorMsg: `fcors: origin patterns like "https://*.co.uk" that encompass ` +
+				`subdomains of a public suffix are by default prohibited`,
+		}, {
+			desc: "specified sites are invalid",
+			options: []fcors.OptionAnon{
+				fcors.FromSites(
+					"https://example.com",
+					"example.com:8080",
+					"*.example.com",
+					"127.0.0.1",
+				),
+			},
+			errorMsg: strings.Join(
+				[]string{
+					`fcors: invalid site "https://example.com"`,
+					`fcors: invalid site "example.com:8080"`,
+					`fcors: invalid site "*.example.com"`,
+					`fcors: invalid site "127.0.0.1"`,
+				}, "\n"),
+		}, {
+			desc: "option FromSites used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromSites("example.com"),
+				fcors.FromSites("example.org"),
+			},
+			errorMsg: `fcors: option FromSites used multiple times`,
+		}, {
+			desc: "conjunct use of options FromSites and FromAnyOrigin",
+			options: []fcors.OptionAnon{
+				fcors.FromSites("example.com"),
+				fcors.FromAnyOrigin(),
+			},
+			errorMsg: `fcors: incompatible options FromSites and FromAnyOrigin`,
+		}, {
+			desc: "option FromSameSiteOrigins used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromSameSiteOrigins(),
+				fcors.FromSameSiteOrigins(),
+			},
+			errorMsg: `fcors: option FromSameSiteOrigins used multiple times`,
+		}, {
+			desc: "option TrustXForwardedHost used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromSameSiteOrigins(),
+				risky.TrustXForwardedHost(),
+				risky.TrustXForwardedHost(),
+			},
+			errorMsg: `fcors/risky: option TrustXForwardedHost used multiple times`,
+		}, {
+			desc: "conjunct use of options FromSameSiteOrigins and FromAnyOrigin",
+			options: []fcors.OptionAnon{
+				fcors.FromSameSiteOrigins(),
+				fcors.FromAnyOrigin(),
+			},
+			errorMsg: `fcors: incompatible options FromSameSiteOrigins and FromAnyOrigin`,
+		}, {
+			desc: "option FromOriginsForHost used multiple times for the same host",
+			options: []fcors.OptionAnon{
+				fcors.FromOriginsForHost("api.example.com", "https://example.com"),
+				fcors.FromOriginsForHost("API.example.com", "https://example.org"),
+			},
+			errorMsg: `fcors: option FromOriginsForHost used multiple times for host "API.example.com"`,
+		}, {
+			desc: "invalid host and origin patterns in option FromOriginsForHost",
+			options: []fcors.OptionAnon{
+				fcors.FromOriginsForHost("api.example.com:8080", "https://example.com"),
+				fcors.FromOriginsForHost("api.example.org", "https://example.org/", "https://*.com"),
+			},
+			errorMsg: strings.Join(
+				[]string{
+					`fcors: invalid host "api.example.com:8080" in option FromOriginsForHost`,
+					`fcors: invalid origin pattern "https://example.org/": trailing slash; did you mean "https://example.org"? (for host "api.example.org")`,
+				}, "\n"),
+		}, {
+			desc: "origin pattern for host encompasses subdomains of a public suffix",
+			options: []fcors.OptionAnon{
+				fcors.FromOriginsForHost("api.example.org", "https://*.com"),
+			},
+			errorMsg: `fcors: origin patterns like "https://*.com" that encompass ` +
+				`subdomains of a public suffix are by default prohibited (for host "api.example.org")`,
+		}, {
+			desc: "conjunct use of options FromOriginsForHost and FromAnyOrigin",
+			options: []fcors.OptionAnon{
+				fcors.FromOriginsForHost("api.example.com", "https://example.com"),
+				fcors.FromAnyOrigin(),
+			},
+			errorMsg: `fcors: incompatible options FromOriginsForHost and FromAnyOrigin`,
+		}, {
+			desc: "empty validity window in option FromOriginsBetween",
+			options: []fcors.OptionAnon{
+				fcors.FromOriginsBetween(
+					time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC),
+					time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+					"https://partner.example",
+				),
+			},
+			errorMsg: `fcors: empty validity window in option FromOriginsBetween: ` +
+				`2026-06-01T00:00:00Z is not before 2026-03-01T00:00:00Z`,
+		}, {
+			desc: "nil clock",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://example.com"),
+				fcors.WithClock(nil),
+			},
+			errorMsg: `fcors: nil clock in option WithClock`,
+		}, {
+			desc: "option WithClock used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://example.com"),
+				fcors.WithClock(time.Now),
+				fcors.WithClock(time.Now),
+			},
+			errorMsg: `fcors: option WithClock used multiple times`,
+		}, {
+			desc: "conjunct use of options FromOriginsUntil and FromAnyOrigin",
+			options: []fcors.OptionAnon{
+				fcors.FromOriginsUntil(time.Now(), "https://partner.example"),
+				fcors.FromAnyOrigin(),
+			},
+			errorMsg: `fcors: incompatible options FromOriginsUntil/FromOriginsBetween and FromAnyOrigin`,
+		}, {
+			desc: "invalid label and origin patterns in option FromLabeledOrigins",
+			options: []fcors.OptionAnon{
+				fcors.FromLabeledOrigins("partner acme", "https://acme.example"),
+				fcors.FromLabeledOrigins("internal", "https://example.com/"),
+			},
+			errorMsg: strings.Join(
+				[]string{
+					`fcors: invalid label "partner acme" in option FromLabeledOrigins`,
+					`fcors: invalid origin pattern "https://example.com/": trailing slash; did you mean "https://example.com"? (for label "internal")`,
+				}, "\n"),
+		}, {
+			desc: "option FromLabeledOrigins used multiple times for the same label",
+			options: []fcors.OptionAnon{
+				fcors.FromLabeledOrigins("internal", "https://example.com"),
+				fcors.FromLabeledOrigins("internal", "https://example.org"),
+			},
+			errorMsg: `fcors: option FromLabeledOrigins used multiple times for label "internal"`,
+		}, {
+			desc: "conjunct use of options FromLabeledOrigins and FromAnyOrigin",
+			options: []fcors.OptionAnon{
+				fcors.FromLabeledOrigins("internal", "https://example.com"),
+				fcors.FromAnyOrigin(),
+			},
+			errorMsg: `fcors: incompatible options FromLabeledOrigins and FromAnyOrigin`,
+		}, {
+			desc: "invalid and duplicate bundle names",
+			options: []fcors.OptionAnon{
+				fcors.Bundle("", fcors.FromOrigins("https://example.com")),
+				fcors.Bundle("platform", fcors.WithMethods(http.MethodPut)),
+				fcors.BundleAnon("platform", fcors.FromAnyOrigin()),
+			},
+			errorMsg: strings.Join(
+				[]string{
+					`fcors: invalid name "" in option Bundle`,
+					`fcors: bundle "platform" used multiple times`,
+					`fcors: incompatible options FromOrigins and FromAnyOrigin`,
+				}, "\n"),
+		}, {
+			desc: "additive options used multiple times within a bundle",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://example.com"),
+				fcors.Bundle(
+					"platform",
+					fcors.FromOrigins("https://example.org"),
+					fcors.FromOrigins("https://example.net"),
+					fcors.Bundle(
+						"monitoring",
+						fcors.WithRequestHeaders("X-Foo"),
+						fcors.WithRequestHeaders("X-Bar"),
+					),
+				),
+			},
+			errorMsg: strings.Join(
+				[]string{
+					`fcors: option FromOrigins used multiple times in bundle "platform"`,
+					`fcors: option WithRequestHeaders used multiple times in bundle "platform/monitoring"`,
+				}, "\n"),
+		}, {
+			desc: "scalar options used with conflicting values in different bundles",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://example.com"),
+				fcors.Bundle("platform", fcors.MaxAgeInSeconds(600), fcors.PreflightSuccessStatus(200)),
+				fcors.Bundle("service", fcors.MaxAgeInSeconds(7200)),
+				fcors.PreflightSuccessStatus(204),
+			},
+			errorMsg: strings.Join(
+				[]string{
+					`fcors: option MaxAgeInSeconds used with conflicting values 600 (in bundle "platform") and 7200 (in bundle "service")`,
+					`fcors: option PreflightSuccessStatus used with conflicting values 200 (in bundle "platform") and 204 (outside any bundle)`,
+				}, "\n"),
+		}, {
+			desc: "scalar option used multiple times outside any bundle after use in a bundle",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://example.com"),
+				fcors.Bundle("platform", fcors.MaxAgeInSeconds(10)),
+				fcors.MaxAgeInSeconds(10),
+				fcors.MaxAgeInSeconds(10),
+			},
+			errorMsg: `fcors: option MaxAgeInSeconds used multiple times`,
+		}, {
+			desc: "scalar option used with a value that conflicts with a non-first use",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://example.com"),
+				fcors.Bundle("platform", fcors.MaxAgeInSeconds(10)),
+				fcors.Bundle("service", fcors.MaxAgeInSeconds(10)),
+				fcors.Bundle("team", fcors.MaxAgeInSeconds(20)),
+			},
+			errorMsg: `fcors: option MaxAgeInSeconds used with conflicting values 10 (in bundle "platform") and 20 (in bundle "team")`,
+		}, {
+			desc: "option EnforceRequestHeaders used multiple times and with option WithAnyRequestHeaders",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.EnforceRequestHeaders(),
+				fcors.EnforceRequestHeaders(),
+				fcors.WithAnyRequestHeaders(),
+			},
+			errorMsg: strings.Join(
+				[]string{
+					"fcors: option EnforceRequestHeaders used multiple times",
+					"fcors: incompatible options EnforceRequestHeaders and WithAnyRequestHeaders",
+				}, "\n"),
+		}, {
+			desc: "invalid, forbidden, and prohibited request-header name patterns",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.WithRequestHeaders(
+					"x-*-foo*",
+					"Sec-*",
+					"se*",
+					"Proxy-Foo-*",
+					"c*",
+					"access-control-allow-*",
+					"x-amz-*",
+				),
+			},
+			errorMsg: strings.Join(
+				[]string{
+					`fcors: invalid request-header name pattern "x-*-foo*"`,
+					`fcors: forbidden request-header name pattern "Sec-*"`,
+					`fcors: forbidden request-header name pattern "se*"`,
+					`fcors: forbidden request-header name pattern "Proxy-Foo-*"`,
+					`fcors: forbidden request-header name pattern "c*"`,
+					`fcors: prohibited request-header name pattern "access-control-allow-*"`,
+				}, "\n"),
+		}, {
+			desc: "invalid uses of option WithAnyRequestHeadersExcept",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.WithAnyRequestHeadersExcept("X-Internal-Auth", "not a valid header", "*"),
+				fcors.WithAnyRequestHeadersExcept("X-Forwarded-For"),
+				fcors.WithRequestHeaders("X-Foo"),
+				fcors.WithAnyRequestHeaders(),
+			},
+			errorMsg: strings.Join(
+				[]string{
+					`fcors: invalid request-header name "not a valid header"`,
+					`fcors: invalid request-header name "*"`,
+					`fcors: option WithAnyRequestHeadersExcept used multiple times`,
+					`fcors: incompatible options WithRequestHeaders and WithAnyRequestHeaders`,
+					`fcors: incompatible options WithRequestHeaders and WithAnyRequestHeadersExcept`,
+					`fcors: incompatible options WithAnyRequestHeaders and WithAnyRequestHeadersExcept`,
+				}, "\n"),
+		}, {
+			desc: "invalid uses of option WithRequestHeadersForMethod",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.WithMethods(http.MethodPut),
+				fcors.WithRequestHeadersForMethod(http.MethodPut, "X-Upload-Id"),
+				fcors.WithRequestHeadersForMethod(http.MethodPut, "Content-Range", "Cookie"),
+				fcors.WithRequestHeadersForMethod("CONNECT", "X-Foo"),
+				fcors.WithRequestHeadersForMethod(http.MethodDelete, "X-Foo"),
+				fcors.WithAnyRequestHeaders(),
+			},
+			errorMsg: strings.Join(
+				[]string{
+					`fcors: forbidden request-header name "cookie" (for method "PUT")`,
+					`fcors: option WithRequestHeadersForMethod used multiple times for method "PUT"`,
+					`fcors: forbidden method name "CONNECT" in option WithRequestHeadersForMethod`,
+					`fcors: incompatible options WithRequestHeadersForMethod and WithAnyRequestHeaders`,
+					`fcors: method "DELETE" in option WithRequestHeadersForMethod is not allowed`,
+				}, "\n"),
+		}, {
+			desc: "invalid uses of option ExposeResponseHeadersAutomatically",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.ExposeResponseHeadersAutomatically(),
+				fcors.ExposeResponseHeadersAutomatically(),
+				fcors.ExposeResponseHeaders("X-Foo"),
+				fcors.ExposeAllResponseHeaders(),
+			},
+			errorMsg: strings.Join(
+				[]string{
+					`fcors: option ExposeResponseHeadersAutomatically used multiple times`,
+					`fcors: incompatible options ExposeResponseHeaders and ExposeResponseHeadersAutomatically`,
+					`fcors: incompatible options ExposeAllResponseHeaders and ExposeResponseHeadersAutomatically`,
+					`fcors: incompatible options ExposeResponseHeaders and ExposeAllResponseHeaders`,
+				}, "\n"),
+		}, {
+			desc: "option PreflightPassthrough used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.PreflightPassthrough(),
+				fcors.PreflightPassthrough(),
+			},
+			errorMsg: `fcors: option PreflightPassthrough used multiple times`,
+		}, {
+			desc: "option PreventSharedCaching used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.PreventSharedCaching(),
+				fcors.PreventSharedCaching(),
+			},
+			errorMsg: `fcors: option PreventSharedCaching used multiple times`,
+		}, {
+			desc: "option AssumeNoWildcardSupport used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.AssumeNoWildcardSupport(),
+				fcors.AssumeNoWildcardSupport(),
+			},
+			errorMsg: `fcors: option AssumeNoWildcardSupport used multiple times`,
+		}, {
+			desc: "option RespondToOptionsRequests used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.RespondToOptionsRequests(),
+				fcors.RespondToOptionsRequests(),
+			},
+			errorMsg: `fcors: option RespondToOptionsRequests used multiple times`,
+		}, {
+			desc: "option RespondToOptionsRequests used with WithAnyMethod and PreflightPassthrough",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.RespondToOptionsRequests(),
+				fcors.WithAnyMethod(),
+				fcors.PreflightPassthrough(),
+			},
+			errorMsg: strings.Join([]string{
+				`fcors: incompatible options RespondToOptionsRequests and WithAnyMethod`,
+				`fcors: incompatible options RespondToOptionsRequests and PreflightPassthrough`,
+			}, "\n"),
+		}, {
+			desc: "excluded origin pattern cannot be same site with any host",
+			options: []fcors.OptionAnon{
+				fcors.FromSameSiteOrigins(),
+				fcors.ExceptOrigins(
+					"https://uploads.example.com",
+					"http://uploads.example.com",
+				),
+			},
+			errorMsg: `fcors: origin pattern "http://uploads.example.com" excluded by option ExceptOrigins does not overlap any allowed origin pattern`,
+		}, {
+			desc: "option WithRouteMethods with nil function and used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.RespondToOptionsRequests(),
+				fcors.WithRouteMethods(nil),
+				fcors.WithRouteMethods(func(*http.Request) []string { return nil }),
+			},
+			errorMsg: strings.Join(
+				[]string{
+					"fcors: nil function in option WithRouteMethods",
+					"fcors: option WithRouteMethods used multiple times",
+				}, "\n"),
+		}, {
+			desc: "option WithRouteMethods without option RespondToOptionsRequests",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.WithRouteMethods(func(*http.Request) []string { return nil }),
+			},
+			errorMsg: `fcors: option WithRouteMethods requires option RespondToOptionsRequests`,
+		}, {
+			desc: "option ExceptOrigins used multiple times",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://*.example.com"),
+				fcors.ExceptOrigins("https://foo.example.com"),
+				fcors.ExceptOrigins("https://bar.example.com"),
+			},
+			errorMsg: `fcors: option ExceptOrigins used multiple times`,
+		}, {
+			desc: "excluded origin pattern does not overlap any allowed origin pattern",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://*.example.com"),
+				fcors.ExceptOrigins(
+					"https://foo.example.com",
+					"https://foo.example.org",
+				),
+			},
+			errorMsg: `fcors: origin pattern "https://foo.example.org" excluded by ` +
+				`option ExceptOrigins does not overlap any allowed origin pattern`,
+		}, {
+			desc: "invalid excluded origin pattern",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://*.example.com"),
+				fcors.ExceptOrigins("https://foo.example.com/"),
+			},
+			errorMsg: `fcors: invalid origin pattern "https://foo.example.com/": trailing slash; did you mean "https://foo.example.com"?`,
+		}, {
+			desc: "conjunct use of options FromAnyOrigin and ExceptOrigins",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.ExceptOrigins("https://foo.example.com"),
+			},
+			errorMsg: `fcors: incompatible options FromAnyOrigin and ExceptOrigins`,
 		}, {
 			desc: "conjunct use of options FromOrigins and FromAnyOrigin",
 			options: []fcors.OptionAnon{
@@ -544,7 +1054,7 @@ func TestInvalidPoliciesForAllowAccess(t *testing.T) {
 			},
 			errorMsg: strings.Join(
 				[]string{
-					`fcors: invalid origin pattern "https://example.com/"`,
+					`fcors: invalid origin pattern "https://example.com/": trailing slash; did you mean "https://example.com"?`,
 					`fcors: forbidden method name "CONNECT"`,
 					`fcors: invalid method name "not a valid method"`,
 					`fcors: option WithMethods used multiple times`,
@@ -591,6 +1101,11 @@ func TestInvalidPoliciesForAllowAccessWithCredentials(t *testing.T) {
 			options: []fcors.Option{fcors.FromOrigins("http://example.com:6060")},
 			errorMsg: `fcors: insecure origin patterns like "http://example.com:6060" ` +
 				`are by default prohibited when credentialed access is enabled`,
+		}, {
+			desc:    "specified origin's host is a non-loopback IP prefix",
+			options: []fcors.Option{fcors.FromOrigins("http://10.0.0.0/8:*")},
+			errorMsg: `fcors: insecure origin patterns like "http://10.0.0.0/8:*" ` +
+				`are by default prohibited when credentialed access is enabled`,
 		}, {
 			desc: "option PrivateNetworkAccess is used and specified origin is insecure",
 			options: []fcors.Option{
@@ -632,19 +1147,19 @@ func TestInvalidPoliciesForAllowAccessWithCredentials(t *testing.T) {
 		}, {
 			desc:     "specified origin contains a path",
 			options:  []fcors.Option{fcors.FromOrigins("https://example.com:6060/foo")},
-			errorMsg: `fcors: invalid origin pattern "https://example.com:6060/foo"`,
+			errorMsg: `fcors: invalid origin pattern "https://example.com:6060/foo": path; did you mean "https://example.com:6060"?`,
 		}, {
 			desc:     "specified origin contains a querystring delimiter",
 			options:  []fcors.Option{fcors.FromOrigins("https://example.com:6060?")},
-			errorMsg: `fcors: invalid origin pattern "https://example.com:6060?"`,
+			errorMsg: `fcors: invalid origin pattern "https://example.com:6060?": query or fragment; did you mean "https://example.com:6060"?`,
 		}, {
 			desc:     "specified origin contains a querystring",
 			options:  []fcors.Option{fcors.FromOrigins("https://example.com:6060?foo=bar")},
-			errorMsg: `fcors: invalid origin pattern "https://example.com:6060?foo=bar"`,
+			errorMsg: `fcors: invalid origin pattern "https://example.com:6060?foo=bar": query or fragment; did you mean "https://example.com:6060"?`,
 		}, {
 			desc:     "specified origin contains a fragment",
 			options:  []fcors.Option{fcors.FromOrigins("https://example.com:6060#index")},
-			errorMsg: `fcors: invalid origin pattern "https://example.com:6060#index"`,
+			errorMsg: `fcors: invalid origin pattern "https://example.com:6060#index": query or fragment; did you mean "https://example.com:6060"?`,
 		}, {
 			desc:     "specified origin contains an invalid port",
 			options:  []fcors.Option{fcors.FromOrigins("https://example.com:66536")},
@@ -721,19 +1236,19 @@ func TestInvalidPoliciesForAllowAccessWithCredentials(t *testing.T) {
 		}, {
 			desc:     "specified base origin contains a path",
 			options:  []fcors.Option{fcors.FromOrigins("https://*.example.com:6060/foo")},
-			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060/foo"`,
+			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060/foo": path; did you mean "https://*.example.com:6060"?`,
 		}, {
 			desc:     "specified base origin contains a querystring",
 			options:  []fcors.Option{fcors.FromOrigins("https://*.example.com:6060?foo=bar")},
-			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060?foo=bar"`,
+			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060?foo=bar": query or fragment; did you mean "https://*.example.com:6060"?`,
 		}, {
 			desc:     "specified origin contains a querystring delimiter",
 			options:  []fcors.Option{fcors.FromOrigins("https://*.example.com:6060?")},
-			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060?"`,
+			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060?": query or fragment; did you mean "https://*.example.com:6060"?`,
 		}, {
 			desc:     "specified base origin contains a fragment",
 			options:  []fcors.Option{fcors.FromOrigins("https://*.example.com:6060#index")},
-			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060#index"`,
+			errorMsg: `fcors: invalid origin pattern "https://*.example.com:6060#index": query or fragment; did you mean "https://*.example.com:6060"?`,
 		}, {
 			desc:     "specified base origin contains an invalid port",
 			options:  []fcors.Option{fcors.FromOrigins("https://*.example.com:66536")},
@@ -761,9 +1276,25 @@ func TestInvalidPoliciesForAllowAccessWithCredentials(t *testing.T) {
 			errorMsg: `fcors: origin patterns like "https://*.github.io" that encompass ` +
 				`subdomains of a public suffix are by default prohibited`,
 		}, {
-			desc:     "missing call to FromOrigins",
-			options:  []fcors.Option{fcors.WithAnyMethod()},
-			errorMsg: `fcors: missing call to FromOrigins in AllowAccessWithCredentials`,
+			desc: "specified base origin's host is a public suffix according to the replacement list",
+			options: []fcors.Option{
+				fcors.FromOrigins("https://*.apps.example.corp"),
+				risky.ReplacePublicSuffixList(publicSuffixes, "psl.dat"),
+			},
+			errorMsg: `fcors: origin patterns like "https://*.apps.example.corp" that encompass ` +
+				`subdomains of a public suffix are by default prohibited`,
+		}, {
+			desc: "insecure origin pattern for host",
+			options: []fcors.Option{
+				fcors.FromOriginsForHost("api.example.com", "http://example.com"),
+			},
+			errorMsg: `fcors: insecure origin patterns like "http://example.com" are by default ` +
+				`prohibited when credentialed access is enabled (for host "api.example.com")`,
+		}, {
+			desc:    "missing call to an option that specifies allowed origins",
+			options: []fcors.Option{fcors.WithAnyMethod()},
+			errorMsg: `fcors: missing call to some option that specifies allowed origins ` +
+				`(e.g. FromOrigins) in AllowAccessWithCredentials`,
 		}, {
 			desc: "empty method name",
 			options: []fcors.Option{
@@ -1066,7 +1597,7 @@ func TestInvalidPoliciesForAllowAccessWithCredentials(t *testing.T) {
 			},
 			errorMsg: strings.Join(
 				[]string{
-					`fcors: invalid origin pattern "https://example.com/"`,
+					`fcors: invalid origin pattern "https://example.com/": trailing slash; did you mean "https://example.com"?`,
 					`fcors: forbidden method name "CONNECT"`,
 					`fcors: invalid method name "not a valid method"`,
 					`fcors: option WithMethods used multiple times`,
@@ -0,0 +1,158 @@
+package fcors_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/jub0bs/fcors"
+	"github.com/jub0bs/fcors/risky"
+)
+
+func TestLint(t *testing.T) {
+	manyOrigins := make([]string, 101)
+	for i := range manyOrigins {
+		manyOrigins[i] = fmt.Sprintf("https://%d.example.com", i)
+	}
+	cases := []struct {
+		desc     string
+		options  []fcors.OptionAnon
+		warnings []fcors.Warning
+	}{
+		{
+			desc: "unquestionable policy",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("https://example.com", "https://*.example.org"),
+				fcors.MaxAgeInSeconds(7200),
+				fcors.ExposeResponseHeaders("X-Foo"),
+			},
+		}, {
+			desc: "redundant origin patterns",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins(
+					"https://foo.example.com",
+					"https://*.example.com",
+					"https://*.bar.example.com",
+					"https://example.com",
+				),
+				fcors.FromOriginsForHost("api.example.org", "https://example.com:*", "https://example.com:9090"),
+			},
+			warnings: []fcors.Warning{
+				{
+					Code:    fcors.WarningRedundantOriginPattern,
+					Message: `origin pattern "https://*.bar.example.com" is redundant with origin pattern "https://*.example.com"`,
+				}, {
+					Code:    fcors.WarningRedundantOriginPattern,
+					Message: `origin pattern "https://foo.example.com" is redundant with origin pattern "https://*.example.com"`,
+				}, {
+					Code:    fcors.WarningRedundantOriginPattern,
+					Message: `origin pattern "https://example.com:9090" is redundant with origin pattern "https://example.com:*" (for host "api.example.org")`,
+				},
+			},
+		}, {
+			desc: "max-age value above Chromium's cap",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.MaxAgeInSeconds(7201),
+			},
+			warnings: []fcors.Warning{
+				{
+					Code: fcors.WarningMaxAgeAboveBrowserCap,
+					Message: "max-age value 7201 exceeds 7200, beyond which " +
+						"Chromium-based browsers (and WebKit-based ones, whose cap is lower) " +
+						"do not cache preflight responses any longer",
+				},
+			},
+		}, {
+			desc: "sensitive exposed response headers",
+			options: []fcors.OptionAnon{
+				fcors.FromAnyOrigin(),
+				fcors.ExposeResponseHeaders("Authorization", "X-CSRF-Token", "X-Foo"),
+			},
+			warnings: []fcors.Warning{
+				{
+					Code:    fcors.WarningSensitiveExposedHeader,
+					Message: `exposed response header "authorization" likely carries secrets`,
+				}, {
+					Code:    fcors.WarningSensitiveExposedHeader,
+					Message: `exposed response header "x-csrf-token" likely carries secrets`,
+				},
+			},
+		}, {
+			desc: "insecure origins",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins("http://example.com", "http://localhost:9090"),
+				fcors.FromLabeledOrigins("legacy", "http://legacy.example.com"),
+			},
+			warnings: []fcors.Warning{
+				{
+					Code:    fcors.WarningInsecureOrigins,
+					Message: `insecure origin patterns like "http://example.com" allow network attackers to read responses`,
+				}, {
+					Code:    fcors.WarningInsecureOrigins,
+					Message: `insecure origin patterns like "http://legacy.example.com" allow network attackers to read responses (for label "legacy")`,
+				},
+			},
+		}, {
+			desc: "large allowlist",
+			options: []fcors.OptionAnon{
+				fcors.FromOrigins(manyOrigins[0], manyOrigins[1:]...),
+			},
+			warnings: []fcors.Warning{
+				{
+					Code: fcors.WarningLargeAllowlist,
+					Message: "101 origin patterns exceed 100; consider origin patterns " +
+						"that encompass arbitrary subdomains",
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			warnings, err := fcors.Lint(c.options[0], c.options[1:]...)
+			if err != nil {
+				t.Fatalf("got error with message %q; want nil error", err.Error())
+			}
+			if !slices.Equal(warnings, c.warnings) {
+				t.Errorf("got warnings\n\t%q\nwant warnings\n\t%q", warnings, c.warnings)
+			}
+		}
+		t.Run(c.desc, f)
+	}
+}
+
+func TestLintWithCredentials(t *testing.T) {
+	warnings, err := fcors.LintWithCredentials(
+		fcors.FromOrigins("http://example.com", "https://example.com"),
+		risky.DangerouslyTolerateInsecureOrigins(),
+	)
+	if err != nil {
+		t.Fatalf("got error with message %q; want nil error", err.Error())
+	}
+	want := []fcors.Warning{
+		{
+			Code:    fcors.WarningInsecureOrigins,
+			Message: `insecure origin patterns like "http://example.com" allow network attackers to read responses`,
+		},
+	}
+	if !slices.Equal(warnings, want) {
+		t.Errorf("got warnings\n\t%q\nwant warnings\n\t%q", warnings, want)
+	}
+}
+
+func TestLintInvalidPolicy(t *testing.T) {
+	warnings, err := fcors.LintWithCredentials(
+		fcors.FromOrigins("http://example.com"),
+	)
+	if err == nil {
+		t.Fatal("got nil error; want non-nil error")
+	}
+	const want = `fcors: insecure origin patterns like "http://example.com" ` +
+		"are by default prohibited when credentialed access is enabled"
+	if err.Error() != want {
+		t.Errorf("got error with message\n\t%q\nwant error with message\n\t%q", err.Error(), want)
+	}
+	if warnings != nil {
+		t.Errorf("got warnings %q; want nil", warnings)
+	}
+}
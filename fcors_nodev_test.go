@@ -0,0 +1,21 @@
+//go:build !fcorsdev
+
+package fcors_test
+
+import (
+	"testing"
+
+	"github.com/jub0bs/fcors"
+)
+
+func Test_AllowAccess_From_Loopback_Origins_Outside_Of_Development(t *testing.T) {
+	for _, v := range []string{"", "false", "yes"} {
+		t.Setenv("FCORS_DEV", v)
+		_, err := fcors.AllowAccess(fcors.FromLoopbackOrigins())
+		const want = "fcors: option FromLoopbackOrigins requires either " +
+			"build tag fcorsdev or environment variable FCORS_DEV=true"
+		if err == nil || err.Error() != want {
+			t.Errorf("FCORS_DEV=%q: got error %v; want error with message %q", v, err, want)
+		}
+	}
+}
@@ -36,6 +36,10 @@ const (
 	headerACEH  = "Access-Control-Expose-Headers"
 	headerVary  = "Vary"
 
+	headerXFH          = "X-Forwarded-Host"
+	headerCacheControl = "Cache-Control"
+	headerAllow        = "Allow"
+
 	varyPreflightValue = headerACRH + ", " + headerACRM + ", " +
 		headerACRPN + ", " + headerOrigin
 
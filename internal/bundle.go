@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jub0bs/fcors/internal/util"
+)
+
+func Bundle(name string, one Option, others ...Option) Option {
+	return option(bundle(optB, name, one, others...))
+}
+
+func BundleAnon(name string, one OptionAnon, others ...OptionAnon) OptionAnon {
+	return optionAnon(bundle(optBA, name, one, others...))
+}
+
+// bundle returns a function that applies the specified options
+// on behalf of the bundle named name.
+func bundle[A applier](optName, name string, one A, others ...A) func(*Config) error {
+	return func(cfg *Config) error {
+		var errs []error
+		if !isValidLabel(name) {
+			const tmpl = "invalid name %q in option %s"
+			errs = append(errs, util.Errorf(tmpl, name, optName))
+		}
+		// Bundles may be nested, in which case we qualify the name of the
+		// inner bundle with that of the outer bundle.
+		outer := cfg.tmp.Bundle
+		if outer != "" {
+			name = outer + "/" + name
+		}
+		if cfg.tmp.BundleNames.Contains(name) {
+			const tmpl = "bundle %q used multiple times"
+			errs = append(errs, util.Errorf(tmpl, name))
+		}
+		if cfg.tmp.BundleNames == nil {
+			cfg.tmp.BundleNames = make(util.Set[string])
+		}
+		cfg.tmp.BundleNames.Add(name)
+		cfg.tmp.Bundle = name
+		if err := one.apply(cfg); err != nil {
+			errs = append(errs, err)
+		}
+		for _, opt := range others {
+			if err := opt.apply(cfg); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		cfg.tmp.Bundle = outer
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		return nil
+	}
+}
+
+// recordUse records a use, on behalf of the current bundle (if any),
+// of option optName, which is composable across bundles.
+// It returns an error if that option was already used
+// on behalf of the same bundle (or outside any bundle).
+func (cfg *Config) recordUse(optName string) error {
+	if cfg.tmp.OptionSources == nil {
+		cfg.tmp.OptionSources = make(map[string]util.Set[string])
+	}
+	sources, found := cfg.tmp.OptionSources[optName]
+	if !found {
+		sources = make(util.Set[string])
+		cfg.tmp.OptionSources[optName] = sources
+	}
+	if sources.Contains(cfg.tmp.Bundle) {
+		msg := "option " + optName + " used multiple times"
+		if cfg.tmp.Bundle != "" {
+			msg += fmt.Sprintf(" in bundle %q", cfg.tmp.Bundle)
+		}
+		return util.NewError(msg)
+	}
+	sources.Add(cfg.tmp.Bundle)
+	return nil
+}
+
+// A scalarUse records which value a scalar option was used with,
+// and on behalf of which bundle (if any).
+type scalarUse struct {
+	value  uint
+	bundle string
+}
+
+// recordScalarUse records a use, on behalf of the current bundle (if any),
+// of scalar option optName with value. It returns an error if that option
+// was already used on behalf of the same bundle (or outside any bundle),
+// or with a different value on behalf of some other bundle.
+func (cfg *Config) recordScalarUse(optName string, value uint) error {
+	if cfg.tmp.ScalarUses == nil {
+		cfg.tmp.ScalarUses = make(map[string][]scalarUse)
+	}
+	uses := cfg.tmp.ScalarUses[optName]
+	cfg.tmp.ScalarUses[optName] = append(uses, scalarUse{
+		value:  value,
+		bundle: cfg.tmp.Bundle,
+	})
+	for _, prev := range uses {
+		if prev.bundle == cfg.tmp.Bundle {
+			msg := "option " + optName + " used multiple times"
+			if cfg.tmp.Bundle != "" {
+				msg += fmt.Sprintf(" in bundle %q", cfg.tmp.Bundle)
+			}
+			return util.NewError(msg)
+		}
+	}
+	for _, prev := range uses {
+		if prev.value != value {
+			const tmpl = "option %s used with conflicting values %d (%s) and %d (%s)"
+			return util.Errorf(tmpl, optName, prev.value, describeSource(prev.bundle),
+				value, describeSource(cfg.tmp.Bundle))
+		}
+	}
+	return nil
+}
+
+// describeSource describes, for error messages, the bundle named name.
+func describeSource(name string) string {
+	if name == "" {
+		return "outside any bundle"
+	}
+	return fmt.Sprintf("in bundle %q", name)
+}
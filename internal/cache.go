@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"net/http"
+	"strings"
+)
+
+// directives that prevent shared caches (e.g. CDNs) from storing a response
+var privateDirectives = []string{"private"}
+
+// mergeCacheControl merges directives into the Cache-Control header
+// in respHeaders. Directives that would allow shared caches to store
+// the response are dropped; directives in respHeaders otherwise take
+// precedence over directives of the same name in directives.
+func mergeCacheControl(respHeaders http.Header, directives []string) {
+	var kept []string
+	for _, v := range respHeaders.Values(headerCacheControl) {
+		for _, d := range splitDirectives(v) {
+			name, hasArg := directiveName(d)
+			switch {
+			case name == "public", name == "s-maxage":
+				continue
+			case name == "private" && hasArg:
+				// The qualified form of private (see
+				// https://www.rfc-editor.org/rfc/rfc9111#section-5.2.2.7)
+				// lets shared caches store the unlisted fields.
+				continue
+			}
+			kept = append(kept, d)
+		}
+	}
+	merged := make([]string, 0, len(directives)+len(kept))
+	for _, d := range directives {
+		if !containsDirective(kept, d) {
+			merged = append(merged, d)
+		}
+	}
+	merged = append(merged, kept...)
+	respHeaders[headerCacheControl] = []string{strings.Join(merged, ", ")}
+}
+
+// splitDirectives splits v into its Cache-Control directives, with
+// surrounding optional whitespace trimmed. It accounts for commas within
+// quoted strings, as in no-cache="foo, bar".
+func splitDirectives(v string) []string {
+	var (
+		directives []string
+		inQuotes   bool
+		start      int
+	)
+	for i := 0; i < len(v); i++ {
+		switch v[i] {
+		case '\\':
+			if inQuotes {
+				i++ // skip the escaped byte
+			}
+		case '"':
+			inQuotes = !inQuotes
+		case comma:
+			if !inQuotes {
+				directives = appendDirective(directives, v[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return appendDirective(directives, v[min(start, len(v)):])
+}
+
+func appendDirective(directives []string, d string) []string {
+	d = strings.Trim(d, " \t")
+	if d == "" {
+		return directives
+	}
+	return append(directives, d)
+}
+
+// directiveName returns the byte-lowercase name of directive d and reports
+// whether d has an argument.
+func directiveName(d string) (string, bool) {
+	name, _, hasArg := strings.Cut(d, "=")
+	return byteLowercase(strings.TrimRight(name, " \t")), hasArg
+}
+
+// containsDirective reports whether directives contains a directive
+// of the same name as d.
+func containsDirective(directives []string, d string) bool {
+	name, _ := directiveName(d)
+	for _, other := range directives {
+		if otherName, _ := directiveName(other); otherName == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestMergeCacheControl(t *testing.T) {
+	cases := []struct {
+		desc       string
+		values     []string
+		directives []string
+		want       string
+	}{
+		{
+			desc:       "no Cache-Control header",
+			directives: []string{"private"},
+			want:       "private",
+		}, {
+			desc:       "unrelated directives",
+			values:     []string{"no-cache, must-revalidate"},
+			directives: []string{"private", "max-age=30"},
+			want:       "private, max-age=30, no-cache, must-revalidate",
+		}, {
+			desc:       "directives allowing shared caching",
+			values:     []string{"public, s-maxage=600", "max-age=60"},
+			directives: []string{"private", "max-age=30"},
+			want:       "private, max-age=60",
+		}, {
+			desc:       "directive already present",
+			values:     []string{"Private"},
+			directives: []string{"private"},
+			want:       "Private",
+		}, {
+			desc:       "qualified private directive",
+			values:     []string{`private="Set-Cookie, X-Foo", no-store`},
+			directives: []string{"private"},
+			want:       "private, no-store",
+		}, {
+			desc:       "quoted comma and empty elements",
+			values:     []string{`, no-cache="X-Foo, X-Bar",,`},
+			directives: []string{"private"},
+			want:       `private, no-cache="X-Foo, X-Bar"`,
+		},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			respHeaders := http.Header{}
+			for _, v := range c.values {
+				respHeaders.Add(headerCacheControl, v)
+			}
+			mergeCacheControl(respHeaders, c.directives)
+			got := respHeaders[headerCacheControl]
+			if want := []string{c.want}; !slices.Equal(got, want) {
+				t.Errorf("got %q; want %q", got, want)
+			}
+		}
+		t.Run(c.desc, f)
+	}
+}
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/jub0bs/fcors/internal/origin"
+)
+
+// A Decision records the outcome of a CORS middleware's processing
+// of a request.
+type Decision struct {
+	// OriginLabel is the label (if any) of the request's origin.
+	OriginLabel string
+	// PreflightFailure is the reason (if any) why a CORS-preflight request
+	// failed; it is empty for other requests and for successful preflights.
+	PreflightFailure PreflightFailure
+}
+
+// A PreflightFailure is a reason why a CORS-preflight request failed.
+type PreflightFailure string
+
+const (
+	OriginNotAllowed               PreflightFailure = "origin-not-allowed"
+	PrivateNetworkAccessNotAllowed PreflightFailure = "private-network-access-not-allowed"
+	MethodNotAllowed               PreflightFailure = "method-not-allowed"
+	RequestHeadersNotAllowed       PreflightFailure = "request-headers-not-allowed"
+)
+
+// A decisionKey is the key under which a *Decision is stored
+// in a request's context.
+type decisionKey struct{}
+
+func RecordDecision(ctx context.Context) (context.Context, *Decision) {
+	d := new(Decision)
+	return context.WithValue(ctx, decisionKey{}, d), d
+}
+
+// decisionOf returns the Decision (if any) in which the outcome of the
+// processing of r is to be recorded.
+func decisionOf(r *http.Request) (*Decision, bool) {
+	d, ok := r.Context().Value(decisionKey{}).(*Decision)
+	return d, ok
+}
+
+// recordPreflightFailure records failure in the Decision (if any)
+// associated with r.
+func recordPreflightFailure(r *http.Request, failure PreflightFailure) {
+	if d, ok := decisionOf(r); ok {
+		d.PreflightFailure = failure
+	}
+}
+
+// An originLabelKey is the key under which the label of an allowed origin
+// is stored in a request's context.
+type originLabelKey struct{}
+
+// OriginLabel returns the label (if any) stored in ctx
+// by a CORS middleware.
+func OriginLabel(ctx context.Context) (string, bool) {
+	label, ok := ctx.Value(originLabelKey{}).(string)
+	return label, ok
+}
+
+// withOriginLabel returns r or, if o (the origin of r) has a label,
+// a shallow copy of r whose context carries that label. It also records
+// that label in the Decision (if any) associated with r.
+func (cfg *Config) withOriginLabel(r *http.Request, o *origin.Origin) *http.Request {
+	label, found := cfg.labelOf(o)
+	if !found {
+		return r
+	}
+	if d, ok := decisionOf(r); ok {
+		d.OriginLabel = label
+	}
+	ctx := context.WithValue(r.Context(), originLabelKey{}, label)
+	return r.WithContext(ctx)
+}
@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"os"
+	"strconv"
+)
+
+const (
+	// name of the build tag that marks development builds
+	devBuildTag = "fcorsdev"
+	// name of the environment variable that marks development environments
+	devEnvVar = "FCORS_DEV"
+)
+
+// devModeEnabled reports whether the program was built with build tag
+// fcorsdev or runs in an environment where FCORS_DEV is set to true.
+func devModeEnabled() bool {
+	if devBuild {
+		return true
+	}
+	enabled, err := strconv.ParseBool(os.Getenv(devEnvVar))
+	return err == nil && enabled
+}
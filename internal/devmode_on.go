@@ -0,0 +1,5 @@
+//go:build fcorsdev
+
+package internal
+
+const devBuild = true
@@ -1,3 +1,4 @@
 // Package internal hides all implementation details of packages
-// [github.com/jub0bs/fcors] and [github.com/jub0bs/fcors/risky].
+// [github.com/jub0bs/fcors], [github.com/jub0bs/fcors/risky],
+// and [github.com/jub0bs/fcors/origin].
 package internal
@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// exposeHeaders sets the Access-Control-Expose-Headers header on the basis
+// of the other headers in respHeaders.
+func exposeHeaders(respHeaders http.Header) {
+	var names []string
+	for name := range respHeaders {
+		name = byteLowercase(name)
+		if !isAutomaticallyExposable(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return
+	}
+	slices.Sort(names)
+	respHeaders[headerExposeHeaders] = []string{strings.Join(names, string(comma))}
+}
+
+// isAutomaticallyExposable reports whether name (which is assumed to be
+// byte-lowercase) is the name of a response header that needs to be exposed
+// and can be exposed.
+func isAutomaticallyExposable(name string) bool {
+	return !safelistedResponseHeaderNames.Contains(name) &&
+		!forbiddenResponseHeaderNames.Contains(name) &&
+		!prohibitedResponseHeaderNames.Contains(name) &&
+		// The middleware itself sets the following headers,
+		// which are of no interest to clients.
+		!strings.HasPrefix(name, "access-control-") &&
+		name != byteLowercase(headerVary)
+}
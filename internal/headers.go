@@ -82,13 +82,61 @@ func isValidHeaderName(raw string) bool {
 	return isToken(raw)
 }
 
+// parseHeaderNameList parses s, the value of an
+// Access-Control-Request-Headers header, as a comma-separated list of
+// header names, which it returns in byte-lowercase form.
+// Fetch-compliant browsers byte-lowercase, sort, and join with commas
+// the names they list in that header (see
+// https://fetch.spec.whatwg.org/#cors-unsafe-request-header-names),
+// but we tolerate optional whitespace around each element.
+// The second result is false if some element of s (including
+// an empty one) is not a valid header name.
+func parseHeaderNameList(s string) ([]string, bool) {
+	names := strings.Split(s, string(comma))
+	for i, name := range names {
+		name = strings.Trim(name, " \t")
+		if !isValidHeaderName(name) {
+			return nil, false
+		}
+		names[i] = byteLowercase(name)
+	}
+	return names, true
+}
+
+// see https://fetch.spec.whatwg.org/#forbidden-header-name
+var forbiddenHeaderNamePrefixes = []string{
+	"proxy-",
+	"sec-",
+}
+
 // see https://fetch.spec.whatwg.org/#forbidden-header-name
 func isForbiddenRequestHeaderName(name string) bool {
 	if discreteForbiddenHeaderNames.Contains(name) {
 		return true
 	}
-	return strings.HasPrefix(name, "proxy-") ||
-		strings.HasPrefix(name, "sec-")
+	for _, prefix := range forbiddenHeaderNamePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// encompassesForbiddenRequestHeaderNames reports whether some forbidden
+// request-header name starts with prefix (which is assumed to be
+// byte-lowercase).
+func encompassesForbiddenRequestHeaderNames(prefix string) bool {
+	for name := range discreteForbiddenHeaderNames {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	for _, forbidden := range forbiddenHeaderNamePrefixes {
+		if strings.HasPrefix(forbidden, prefix) || strings.HasPrefix(prefix, forbidden) {
+			return true
+		}
+	}
+	return false
 }
 
 // fastAdd allows us to save a heap allocation in the most common case.
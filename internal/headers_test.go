@@ -2,6 +2,7 @@ package internal
 
 import (
 	"net/http"
+	"slices"
 	"testing"
 )
 
@@ -44,8 +45,11 @@ func TestThatAllRelevantHeaderNamesAreInCanonicalFormat(t *testing.T) {
 		headerAllowOrigin,
 		headerAllowCredentials,
 		headerExposeHeaders,
+		headerXForwardedHost,
 		headerVary,
 		headerAuthorization,
+		headerCacheControl,
+		headerAllow,
 	}
 	for _, name := range headerNames {
 		if http.CanonicalHeaderKey(name) != name {
@@ -53,3 +57,28 @@ func TestThatAllRelevantHeaderNamesAreInCanonicalFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestParseHeaderNameList(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []string
+		ok    bool
+	}{
+		{input: "x-foo", want: []string{"x-foo"}, ok: true},
+		{input: "authorization,x-foo", want: []string{"authorization", "x-foo"}, ok: true},
+		{input: "X-Foo , \tX-Bar", want: []string{"x-foo", "x-bar"}, ok: true},
+		{input: ""},
+		{input: ","},
+		{input: "x-foo,"},
+		{input: "x-foo,,x-bar"},
+		{input: "x foo"},
+		{input: "x-foo;x-bar"},
+	}
+	for _, c := range cases {
+		got, ok := parseHeaderNameList(c.input)
+		if ok != c.ok || !slices.Equal(got, c.want) {
+			const tmpl = "parseHeaderNameList(%q): got %q, %t; want %q, %t"
+			t.Errorf(tmpl, c.input, got, ok, c.want, c.ok)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jub0bs/fcors/internal/origin"
+	"github.com/jub0bs/fcors/internal/util"
+)
+
+// codes of the warnings that Lint may report
+const (
+	WarnRedundantOriginPattern = "redundant-origin-pattern"
+	WarnMaxAgeAboveBrowserCap  = "max-age-above-browser-cap"
+	WarnSensitiveExposedHeader = "sensitive-exposed-header"
+	WarnInsecureOrigins        = "insecure-origins"
+	WarnLargeAllowlist         = "large-allowlist"
+)
+
+const (
+	// Chromium caps the max-age value of preflight responses to 2h;
+	// see the note in MaxAgeInSeconds.
+	chromiumMaxAgeCap = 7200
+	// beyond that many origin patterns, an allowlist is likely unwieldy
+	maxOriginPatternsBeforeWarning = 100
+)
+
+// sensitiveResponseHeaderNames contains the byte-lowercase names of response
+// headers that typically carry credentials or other secrets; note that
+// Set-Cookie and Set-Cookie2 are forbidden response-header names.
+var sensitiveResponseHeaderNames = util.NewSet(
+	"authorization",
+	"proxy-authorization",
+	"proxy-authenticate",
+	"www-authenticate",
+)
+
+// A Warning describes a questionable aspect of a valid CORS policy.
+type Warning struct {
+	// Code identifies the kind of problem (e.g. "redundant-origin-pattern").
+	Code string
+	// Message describes the problem.
+	Message string
+}
+
+func (w Warning) String() string {
+	return w.Message
+}
+
+func Lint[A applier](cred bool, one A, others ...A) ([]Warning, error) {
+	cfg, err := newValidConfig(cred, one, others...)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.lint(), nil
+}
+
+// lint returns warnings about cfg, which is assumed to be valid and
+// not to have been subjected to precomputeStuff yet.
+func (cfg *Config) lint() []Warning {
+	var warnings []Warning
+	defaultOrigins := hostOrigins{
+		patterns:               cfg.tmp.OriginPatterns,
+		insecureOriginPatterns: cfg.tmp.InsecureOriginPatterns,
+	}
+	groups := []*hostOrigins{&defaultOrigins}
+	for i := range cfg.tmp.HostOrigins {
+		groups = append(groups, &cfg.tmp.HostOrigins[i])
+	}
+	for i := range cfg.tmp.TimedOrigins {
+		groups = append(groups, &cfg.tmp.TimedOrigins[i].hostOrigins)
+	}
+	for i := range cfg.tmp.LabeledOrigins {
+		groups = append(groups, &cfg.tmp.LabeledOrigins[i])
+	}
+	for _, ho := range groups {
+		warnings = append(warnings, lintOrigins(ho)...)
+	}
+	if cfg.tmp.MaxAgeInSeconds > chromiumMaxAgeCap {
+		const tmpl = "max-age value %d exceeds %d, beyond which " +
+			"Chromium-based browsers (and WebKit-based ones, whose cap is lower) " +
+			"do not cache preflight responses any longer"
+		w := Warning{
+			Code:    WarnMaxAgeAboveBrowserCap,
+			Message: fmt.Sprintf(tmpl, cfg.tmp.MaxAgeInSeconds, chromiumMaxAgeCap),
+		}
+		warnings = append(warnings, w)
+	}
+	for _, name := range sortedElems(cfg.tmp.ExposedResponseHeaders) {
+		if !isSensitiveResponseHeaderName(name) {
+			continue
+		}
+		const tmpl = "exposed response header %q likely carries secrets"
+		w := Warning{
+			Code:    WarnSensitiveExposedHeader,
+			Message: fmt.Sprintf(tmpl, name),
+		}
+		warnings = append(warnings, w)
+	}
+	return warnings
+}
+
+// lintOrigins returns warnings about the origin patterns in ho.
+func lintOrigins(ho *hostOrigins) []Warning {
+	var warnings []Warning
+	if len(ho.insecureOriginPatterns) > 0 {
+		var msg strings.Builder
+		msg.WriteString(`insecure origin patterns like "`)
+		msg.WriteString(strings.Join(ho.insecureOriginPatterns, `", "`))
+		msg.WriteString(`" allow network attackers to read responses`)
+		msg.WriteString(ho.errorMsgSuffix())
+		w := Warning{
+			Code:    WarnInsecureOrigins,
+			Message: msg.String(),
+		}
+		warnings = append(warnings, w)
+	}
+	patterns := make([]origin.Pattern, 0, len(ho.patterns))
+	for pattern := range ho.patterns {
+		patterns = append(patterns, pattern)
+	}
+	slices.SortFunc(patterns, func(a, b origin.Pattern) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	for i := range patterns {
+		for j := range patterns {
+			if i == j || !patterns[j].Encompasses(&patterns[i]) {
+				continue
+			}
+			const tmpl = "origin pattern %q is redundant with origin pattern %q%s"
+			msg := fmt.Sprintf(tmpl, &patterns[i], &patterns[j], ho.errorMsgSuffix())
+			w := Warning{
+				Code:    WarnRedundantOriginPattern,
+				Message: msg,
+			}
+			warnings = append(warnings, w)
+			break
+		}
+	}
+	if len(patterns) > maxOriginPatternsBeforeWarning {
+		const tmpl = "%d origin patterns exceed %d; consider origin patterns " +
+			"that encompass arbitrary subdomains%s"
+		msg := fmt.Sprintf(tmpl, len(patterns), maxOriginPatternsBeforeWarning, ho.errorMsgSuffix())
+		w := Warning{
+			Code:    WarnLargeAllowlist,
+			Message: msg,
+		}
+		warnings = append(warnings, w)
+	}
+	return warnings
+}
+
+// isSensitiveResponseHeaderName reports whether name (which is assumed to be
+// byte-lowercase) is the name of a response header that typically carries
+// credentials or other secrets.
+func isSensitiveResponseHeaderName(name string) bool {
+	return sensitiveResponseHeaderNames.Contains(name) ||
+		strings.Contains(name, "cookie") ||
+		strings.Contains(name, "token")
+}
+
+// sortedElems returns the elements of set in lexicographical order.
+func sortedElems(set util.Set[string]) []string {
+	elems := make([]string, 0, len(set))
+	for e := range set {
+		elems = append(elems, e)
+	}
+	slices.Sort(elems)
+	return elems
+}
@@ -2,8 +2,13 @@ package internal
 
 import (
 	"errors"
+	"maps"
+	"net"
 	"net/http"
+	"net/netip"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/jub0bs/fcors/internal/origin"
 	"github.com/jub0bs/fcors/internal/util"
@@ -26,19 +31,38 @@ const (
 
 	headerExposeHeaders = "Access-Control-Expose-Headers"
 
+	headerXForwardedHost = "X-Forwarded-Host"
+
 	headerVary          = "Vary"
+	headerAllow         = "Allow"
 	headerAuthorization = "Authorization"
+	headerCacheControl  = "Cache-Control"
 	headerValueTrue     = "true"
 
+	schemeHTTPS = "https"
+
 	wildcard = "*"
 	comma    = ','
 )
 
+// upper bounds on the values of Access-Control-Request-Method and
+// Access-Control-Request-Headers that we're willing to echo back;
+// values sent by browsers are well below those bounds.
+const (
+	maxACRMLen   = 64
+	maxACRHLen   = 1024
+	maxACRHElems = 64
+)
+
 var (
 	// effective constants (precomputed as a micro-optimization)
 	precomputedPreflightVaryValue []string
 	precomputedTrue               = []string{headerValueTrue}
 	precomputedHeaderOrigin       = []string{headerOrigin}
+	// used instead of the above when the trusted X-Forwarded-Host header
+	// influences which origins are allowed
+	precomputedPreflightVaryValueXFH []string
+	precomputedHeaderOriginXFH       = []string{headerOrigin + ", " + headerXForwardedHost}
 )
 
 type Middleware = func(http.Handler) http.Handler
@@ -54,44 +78,128 @@ func init() {
 	b.WriteString(commaSpace)
 	b.WriteString(headerOrigin)
 	precomputedPreflightVaryValue = []string{b.String()}
+	b.WriteString(commaSpace)
+	b.WriteString(headerXForwardedHost)
+	precomputedPreflightVaryValueXFH = []string{b.String()}
 }
 
 type TempConfig struct {
-	PublicSuffixes                                []string
-	InsecureOriginPatterns                        []string
-	SingleNonWildcardOrigin                       string
-	AllowedMethods                                util.Set[string]
-	AllowedRequestHeaders                         util.Set[string]
-	CustomPreflightSuccessStatus                  bool
-	DangerouslyTolerateSubdomainsOfPublicSuffixes bool
-	DangerouslyTolerateInsecureOrigins            bool
-	FromOriginsCalled                             bool
-	WithMethodsCalled                             bool
-	WithRequestHeadersCalled                      bool
-	MaxAgeInSecondsCalled                         bool
-	ExposeResponseHeadersCalled                   bool
+	// nil means origin.DefaultPublicSuffixList
+	PublicSuffixList           origin.PublicSuffixList
+	SubdomainPatterns          []rawPattern
+	SubdomainsAndPortsPatterns []string
+	InsecureOriginPatterns     []string
+	OriginPatterns             util.Set[origin.Pattern]
+	HostOrigins                []hostOrigins
+	TimedOrigins               []timedOrigins
+	LabeledOrigins             []hostOrigins
+	ExcludedOriginPatterns     []rawPattern
+	AllowedMethods             util.Set[string]
+	AllowedRequestHeaders      util.Set[string]
+	// byte-lowercase prefixes of allowed request-header names
+	AllowedRequestHeaderPrefixes util.Set[string]
+	// maps methods to request headers allowed only for them
+	MethodRequestHeaders   map[string]util.Set[string]
+	ExposedResponseHeaders util.Set[string]
+	MaxAgeInSeconds        uint
+	// name of the bundle whose options are being applied, if any
+	Bundle      string
+	BundleNames util.Set[string]
+	// maps the names of options that are composable across bundles
+	// to the names of the bundles that used them ("" outside any bundle)
+	OptionSources map[string]util.Set[string]
+	// maps the names of scalar options to their uses, in order
+	ScalarUses                                      map[string][]scalarUse
+	DangerouslyTolerateSubdomainsOfPublicSuffixes   bool
+	DangerouslyTolerateInsecureOrigins              bool
+	DangerouslyTolerateSubdomainsWithArbitraryPorts bool
+	FromOriginsCalled                               bool
+	FromLoopbackOriginsCalled                       bool
+	FromSitesCalled                                 bool
+	FromOriginsForHostCalled                        bool
+	FromOriginsBetweenCalled                        bool
+	FromLabeledOriginsCalled                        bool
+	WithClockCalled                                 bool
+	ExceptOriginsCalled                             bool
+	AdditionalPublicSuffixesCalled                  bool
+	ReplacePublicSuffixListCalled                   bool
+	WithMethodsCalled                               bool
+	WithRequestHeadersCalled                        bool
+	WithRequestHeadersForMethodCalled               bool
+	PreflightSuccessStatusCalled                    bool
+	AssumeNoWildcardSupport                         bool
+	RespondToOptionsRequests                        bool
+	WithRouteMethodsCalled                          bool
+	ExposeResponseHeadersCalled                     bool
+}
+
+// A rawPattern is an origin pattern along with its raw representation,
+// which is useful for reporting errors.
+type rawPattern struct {
+	raw     string
+	pattern origin.Pattern
 }
 
 type Config struct {
 	// A nil ACAO indicates that the corresponding header
 	// is set dynamically.
-	ACAO                                 []string
-	ACAM                                 []string
-	Corpus                               origin.Corpus
-	tmp                                  *TempConfig
-	ACAH                                 []string
-	ACMA                                 []string
-	PreflightSuccessStatus               int
-	AllowAnyMethod                       bool
-	AllowAnyRequestHeaders               bool
-	AllowAnyOrigin                       bool
-	AllowCredentials                     bool
-	ExposeAllResponseHeaders             bool
+	ACAO   []string
+	ACAM   []string
+	Corpus *origin.Corpus
+	// HostCorpora (if non-nil) maps hosts to dedicated corpora.
+	HostCorpora map[string]*origin.Corpus
+	// corpora of origins allowed only during some validity window
+	TimedCorpora []timedCorpus
+	// Now reports the current time; used only if TimedCorpora is non-empty.
+	Now func() time.Time
+	// corpora of origins that share some label
+	LabeledCorpora []labeledCorpus
+	// nil unless AllowSameSiteOrigins is true
+	PublicSuffixList origin.PublicSuffixList
+	tmp              *TempConfig
+	ACAH             []string
+	// nil unless EnforceRequestHeaders is true
+	// or some prefixes of request-header names are allowed
+	AllowedRequestHeaders util.Set[string]
+	// byte-lowercase prefixes of allowed request-header names
+	AllowedRequestHeaderPrefixes []string
+	// maps methods to request headers allowed only for them
+	MethodRequestHeaders map[string]util.Set[string]
+	// byte-lowercase names of denied request headers;
+	// non-nil only if option WithAnyRequestHeadersExcept was used
+	DeniedRequestHeaders   util.Set[string]
+	ACMA                   []string
+	PreflightSuccessStatus int
+	AllowAnyMethod         bool
+	AllowAnyRequestHeaders bool
+	EnforceRequestHeaders  bool
+	AllowAnyOrigin         bool
+	AllowSameSiteOrigins   bool
+	TrustXForwardedHost    bool
+	// whether responses vary on the X-Forwarded-Host header
+	VaryXForwardedHost                 bool
+	AllowCredentials                   bool
+	ExposeAllResponseHeaders           bool
+	ExposeResponseHeadersAutomatically bool
+	PreflightPassthrough               bool
+	// whether passed-through preflight responses default to
+	// PreflightSuccessStatus (true only if that option was used)
+	PreflightPassthroughDefaultStatus    bool
+	PreventSharedCaching                 bool
 	PrivateNetworkAccess                 bool
 	PrivateNetworkAccessInNoCORSModeOnly bool
 	ACEH                                 []string
-	//lint:ignore U1000 because we pad to the end of the 3rd cache line
-	_padding40 [40]bool
+	// Cache-Control directives for preflight responses;
+	// nil unless PreventSharedCaching is true
+	PreflightCacheControl []string
+	// value of the Allow header in responses to non-preflight OPTIONS
+	// requests; nil unless RespondToOptionsRequests was used
+	Allow []string
+	// reports the methods supported by the requested route;
+	// nil unless option WithRouteMethods was used
+	RouteMethods func(*http.Request) []string
+	//lint:ignore U1000 because we pad to the end of the 6th cache line
+	_padding33 [33]bool
 }
 
 func newConfig(creds bool) *Config {
@@ -105,51 +213,80 @@ func newConfig(creds bool) *Config {
 
 func (cfg *Config) validate() error {
 	var errs []error
-	if len(cfg.tmp.InsecureOriginPatterns) > 0 &&
-		(cfg.AllowCredentials || cfg.PrivateNetworkAccess || cfg.PrivateNetworkAccessInNoCORSModeOnly) &&
-		!cfg.tmp.DangerouslyTolerateInsecureOrigins {
-		// Note: We don't require risky.DangerouslyTolerateInsecureOrigins
-		// when users specify one or more insecure origin patterns
-		// in anonymous-only mode and without PNA;
-		// in such cases, insecure origins like http://example.com
-		// are indeed no less insecure than * is,
-		// which itself doesn't require risky.DangerouslyTolerateInsecureOrigins.
-		var errorMsg strings.Builder
-		var patterns = cfg.tmp.InsecureOriginPatterns
-		errorMsg.WriteString(`insecure origin patterns like "`)
-		errorMsg.WriteString(strings.Join(patterns, `", "`))
-		errorMsg.WriteString(`" are by default prohibited when `)
-		if cfg.AllowCredentials {
-			errorMsg.WriteString("credentialed access is enabled")
-		}
-		if cfg.PrivateNetworkAccess || cfg.PrivateNetworkAccessInNoCORSModeOnly {
-			if cfg.AllowCredentials {
-				errorMsg.WriteString(" and/or ")
-			}
-			errorMsg.WriteString("Private-Network Access is enabled")
-		}
-		err := util.NewError(errorMsg.String())
-		errs = append(errs, err)
+	defaultOrigins := hostOrigins{
+		subdomainPatterns:          cfg.tmp.SubdomainPatterns,
+		subdomainsAndPortsPatterns: cfg.tmp.SubdomainsAndPortsPatterns,
+		insecureOriginPatterns:     cfg.tmp.InsecureOriginPatterns,
 	}
-	if len(cfg.tmp.PublicSuffixes) > 0 && !cfg.tmp.DangerouslyTolerateSubdomainsOfPublicSuffixes {
-		var errorMsg strings.Builder
-		errorMsg.WriteString(`origin patterns like "`)
-		errorMsg.WriteString(strings.Join(cfg.tmp.PublicSuffixes, `", "`))
-		errorMsg.WriteString(`" that encompass subdomains of a public suffix`)
-		errorMsg.WriteString(" are by default prohibited")
-		err := util.NewError(errorMsg.String())
-		errs = append(errs, err)
+	errs = append(errs, cfg.validateOrigins(&defaultOrigins)...)
+	for _, ho := range cfg.tmp.HostOrigins {
+		errs = append(errs, cfg.validateOrigins(&ho)...)
+	}
+	for _, to := range cfg.tmp.TimedOrigins {
+		errs = append(errs, cfg.validateOrigins(&to.hostOrigins)...)
+	}
+	for _, lo := range cfg.tmp.LabeledOrigins {
+		errs = append(errs, cfg.validateOrigins(&lo)...)
+	}
+	if cfg.tmp.AdditionalPublicSuffixesCalled && cfg.tmp.ReplacePublicSuffixListCalled {
+		const msg = "incompatible options " + optAPS + " and " + optRPSL
+		errs = append(errs, util.NewError(msg))
 	}
 	if cfg.tmp.FromOriginsCalled && cfg.AllowAnyOrigin {
 		const msg = "incompatible options " + optFO + " and " + optFAO
 		errs = append(errs, util.NewError(msg))
 	}
-	if !cfg.AllowAnyOrigin && !cfg.tmp.FromOriginsCalled {
+	if cfg.tmp.FromLoopbackOriginsCalled && cfg.AllowAnyOrigin {
+		const msg = "incompatible options " + optFLO + " and " + optFAO
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.tmp.FromSitesCalled && cfg.AllowAnyOrigin {
+		const msg = "incompatible options " + optFS + " and " + optFAO
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.tmp.FromOriginsForHostCalled && cfg.AllowAnyOrigin {
+		const msg = "incompatible options " + optFOFH + " and " + optFAO
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.tmp.FromOriginsBetweenCalled && cfg.AllowAnyOrigin {
+		const msg = "incompatible options " + optFOU + "/" + optFOB + " and " + optFAO
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.tmp.FromLabeledOriginsCalled && cfg.AllowAnyOrigin {
+		const msg = "incompatible options " + optFLbO + " and " + optFAO
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.AllowSameSiteOrigins && cfg.AllowAnyOrigin {
+		const msg = "incompatible options " + optFSSO + " and " + optFAO
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.tmp.ExceptOriginsCalled && cfg.AllowAnyOrigin {
+		const msg = "incompatible options " + optFAO + " and " + optEO
+		errs = append(errs, util.NewError(msg))
+	}
+	for _, excluded := range cfg.tmp.ExcludedOriginPatterns {
+		if cfg.tmp.OriginPatterns == nil &&
+			len(cfg.tmp.HostOrigins) == 0 &&
+			len(cfg.tmp.TimedOrigins) == 0 &&
+			len(cfg.tmp.LabeledOrigins) == 0 &&
+			!cfg.AllowSameSiteOrigins {
+			// No option that specifies allowed origins succeeded; no need to pile on.
+			break
+		}
+		if !cfg.overlapsAllowedOrigins(&excluded.pattern) {
+			const tmpl = "origin pattern %q excluded by option " + optEO +
+				" does not overlap any allowed origin pattern"
+			errs = append(errs, util.Errorf(tmpl, excluded.raw))
+		}
+	}
+	if !cfg.AllowAnyOrigin && !cfg.originSourceSpecified() {
 		if cfg.AllowCredentials {
-			const msg = "missing call to " + optFO + " in AllowAccessWithCredentials"
+			const msg = "missing call to some option that specifies allowed origins" +
+				" (e.g. " + optFO + ") in AllowAccessWithCredentials"
 			errs = append(errs, util.NewError(msg))
 		} else {
-			const msg = "missing call to " + optFO + " or " + optFAO + " in AllowAccess"
+			const msg = "missing call to some option that specifies allowed origins" +
+				" (e.g. " + optFO + " or " + optFAO + ") in AllowAccess"
 			errs = append(errs, util.NewError(msg))
 		}
 	}
@@ -161,6 +298,34 @@ func (cfg *Config) validate() error {
 		const msg = "incompatible options " + optWRH + " and " + optWARH
 		errs = append(errs, util.NewError(msg))
 	}
+	if cfg.DeniedRequestHeaders != nil && cfg.tmp.WithRequestHeadersCalled {
+		const msg = "incompatible options " + optWRH + " and " + optWARHE
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.DeniedRequestHeaders != nil && cfg.AllowAnyRequestHeaders {
+		const msg = "incompatible options " + optWARH + " and " + optWARHE
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.tmp.WithRequestHeadersForMethodCalled && cfg.AllowAnyRequestHeaders {
+		const msg = "incompatible options " + optWRHFM + " and " + optWARH
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.tmp.WithRequestHeadersForMethodCalled && cfg.DeniedRequestHeaders != nil {
+		const msg = "incompatible options " + optWRHFM + " and " + optWARHE
+		errs = append(errs, util.NewError(msg))
+	}
+	for _, method := range slices.Sorted(maps.Keys(cfg.tmp.MethodRequestHeaders)) {
+		if !cfg.AllowAnyMethod &&
+			!safelistedMethods.Contains(method) &&
+			!cfg.tmp.AllowedMethods.Contains(method) {
+			const tmpl = "method %q in option " + optWRHFM + " is not allowed"
+			errs = append(errs, util.Errorf(tmpl, method))
+		}
+	}
+	if cfg.EnforceRequestHeaders && cfg.AllowAnyRequestHeaders {
+		const msg = "incompatible options " + optERqH + " and " + optWARH
+		errs = append(errs, util.NewError(msg))
+	}
 	if cfg.PrivateNetworkAccess && cfg.PrivateNetworkAccessInNoCORSModeOnly {
 		const msg = "incompatible options " + optPNA + " and " + optPNANC
 		errs = append(errs, util.NewError(msg))
@@ -177,6 +342,27 @@ func (cfg *Config) validate() error {
 		const msg = "incompatible options " + optFAO + " and " + optPNANC
 		errs = append(errs, util.NewError(msg))
 	}
+	if cfg.tmp.ExposeResponseHeadersCalled && cfg.ExposeResponseHeadersAutomatically {
+		const msg = "incompatible options " + optERH + " and " + optERHA
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.ExposeAllResponseHeaders && cfg.ExposeResponseHeadersAutomatically {
+		const msg = "incompatible options " + optEARH + " and " + optERHA
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.tmp.RespondToOptionsRequests && cfg.AllowAnyMethod {
+		// The Allow header cannot list arbitrary methods.
+		const msg = "incompatible options " + optRTOR + " and " + optWAM
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.tmp.RespondToOptionsRequests && cfg.PreflightPassthrough {
+		const msg = "incompatible options " + optRTOR + " and " + optPP
+		errs = append(errs, util.NewError(msg))
+	}
+	if cfg.tmp.WithRouteMethodsCalled && !cfg.tmp.RespondToOptionsRequests {
+		const msg = "option " + optWRTM + " requires option " + optRTOR
+		errs = append(errs, util.NewError(msg))
+	}
 	if cfg.tmp.ExposeResponseHeadersCalled && cfg.ExposeAllResponseHeaders {
 		const msg = "incompatible options " + optERH + " and " + optEARH
 		errs = append(errs, util.NewError(msg))
@@ -187,45 +373,294 @@ func (cfg *Config) validate() error {
 	return nil
 }
 
+// validateOrigins checks the origin patterns in ho against the restrictions
+// that apply to allowed origins.
+func (cfg *Config) validateOrigins(ho *hostOrigins) []error {
+	var errs []error
+	if len(ho.insecureOriginPatterns) > 0 &&
+		(cfg.AllowCredentials || cfg.PrivateNetworkAccess || cfg.PrivateNetworkAccessInNoCORSModeOnly) &&
+		!cfg.tmp.DangerouslyTolerateInsecureOrigins {
+		// Note: We don't require risky.DangerouslyTolerateInsecureOrigins
+		// when users specify one or more insecure origin patterns
+		// in anonymous-only mode and without PNA;
+		// in such cases, insecure origins like http://example.com
+		// are indeed no less insecure than * is,
+		// which itself doesn't require risky.DangerouslyTolerateInsecureOrigins.
+		var errorMsg strings.Builder
+		var patterns = ho.insecureOriginPatterns
+		errorMsg.WriteString(`insecure origin patterns like "`)
+		errorMsg.WriteString(strings.Join(patterns, `", "`))
+		errorMsg.WriteString(`" are by default prohibited when `)
+		if cfg.AllowCredentials {
+			errorMsg.WriteString("credentialed access is enabled")
+		}
+		if cfg.PrivateNetworkAccess || cfg.PrivateNetworkAccessInNoCORSModeOnly {
+			if cfg.AllowCredentials {
+				errorMsg.WriteString(" and/or ")
+			}
+			errorMsg.WriteString("Private-Network Access is enabled")
+		}
+		errorMsg.WriteString(ho.errorMsgSuffix())
+		err := util.NewError(errorMsg.String())
+		errs = append(errs, err)
+	}
+	if len(ho.subdomainsAndPortsPatterns) > 0 &&
+		!cfg.tmp.DangerouslyTolerateSubdomainsWithArbitraryPorts {
+		var errorMsg strings.Builder
+		errorMsg.WriteString(`origin patterns like "`)
+		errorMsg.WriteString(strings.Join(ho.subdomainsAndPortsPatterns, `", "`))
+		errorMsg.WriteString(`" that specify both arbitrary subdomains`)
+		errorMsg.WriteString(" and arbitrary ports are by default prohibited")
+		errorMsg.WriteString(ho.errorMsgSuffix())
+		err := util.NewError(errorMsg.String())
+		errs = append(errs, err)
+	}
+	publicSuffixes := cfg.subdomainsOfPublicSuffixes(ho.subdomainPatterns)
+	for _, raw := range publicSuffixes {
+		if raw.pattern.HasArbitrarySubdomainsAndPorts() {
+			// Regardless of any risky option, we deem this combination
+			// too dangerous to be tolerated.
+			const tmpl = "origin pattern %q that specifies both arbitrary " +
+				"subdomains of a public suffix and arbitrary ports is prohibited%s"
+			errs = append(errs, util.Errorf(tmpl, raw.raw, ho.errorMsgSuffix()))
+		}
+	}
+	if len(publicSuffixes) > 0 && !cfg.tmp.DangerouslyTolerateSubdomainsOfPublicSuffixes {
+		var errorMsg strings.Builder
+		errorMsg.WriteString(`origin patterns like "`)
+		for i, raw := range publicSuffixes {
+			if i > 0 {
+				errorMsg.WriteString(`", "`)
+			}
+			errorMsg.WriteString(raw.raw)
+		}
+		errorMsg.WriteString(`" that encompass subdomains of a public suffix`)
+		errorMsg.WriteString(" are by default prohibited")
+		errorMsg.WriteString(ho.errorMsgSuffix())
+		err := util.NewError(errorMsg.String())
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// subdomainsOfPublicSuffixes returns those of patterns that encompass
+// arbitrary subdomains of a public suffix, according to the public-suffix
+// list in effect.
+func (cfg *Config) subdomainsOfPublicSuffixes(patterns []rawPattern) []rawPattern {
+	psl := cfg.tmp.PublicSuffixList
+	if psl == nil {
+		psl = origin.DefaultPublicSuffixList
+	}
+	var res []rawPattern
+	for _, sp := range patterns {
+		if _, isEffectiveTLD := sp.pattern.HostIsEffectiveTLD(psl); isEffectiveTLD {
+			res = append(res, sp)
+		}
+	}
+	return res
+}
+
 func (cfg *Config) precomputeStuff() {
 	precomputedWildcard := []string{wildcard}
 	// precompute ACAO if it can be static
 	switch {
 	case !cfg.AllowCredentials && cfg.AllowAnyOrigin:
 		cfg.ACAO = precomputedWildcard
-	case cfg.tmp.SingleNonWildcardOrigin != "":
-		cfg.ACAO = []string{cfg.tmp.SingleNonWildcardOrigin}
+	case cfg.allowsSingleOrigin():
+		for pattern := range cfg.tmp.OriginPatterns {
+			// The pattern may have been specified with its host in Unicode
+			// form, but we need the origin's ASCII serialization.
+			cfg.ACAO = []string{pattern.String()}
+		}
+	default:
+		cfg.Corpus = cfg.buildCorpus(cfg.tmp.OriginPatterns)
+	}
+	if len(cfg.tmp.HostOrigins) > 0 {
+		cfg.HostCorpora = make(map[string]*origin.Corpus, len(cfg.tmp.HostOrigins))
+		for _, ho := range cfg.tmp.HostOrigins {
+			cfg.HostCorpora[ho.host] = cfg.buildCorpus(ho.patterns)
+		}
+	}
+	for _, to := range cfg.tmp.TimedOrigins {
+		tc := timedCorpus{
+			notBefore: to.notBefore,
+			notAfter:  to.notAfter,
+			corpus:    cfg.buildCorpus(to.patterns),
+		}
+		cfg.TimedCorpora = append(cfg.TimedCorpora, tc)
+	}
+	for _, lo := range cfg.tmp.LabeledOrigins {
+		lc := labeledCorpus{
+			label:  lo.label,
+			corpus: cfg.buildCorpus(lo.patterns),
+		}
+		cfg.LabeledCorpora = append(cfg.LabeledCorpora, lc)
+	}
+	// Which origins are allowed may then depend on X-Forwarded-Host.
+	cfg.VaryXForwardedHost = cfg.TrustXForwardedHost &&
+		(cfg.AllowSameSiteOrigins || len(cfg.HostCorpora) > 0)
+	if len(cfg.TimedCorpora) > 0 && cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.AllowSameSiteOrigins {
+		cfg.PublicSuffixList = cfg.tmp.PublicSuffixList
+		if cfg.PublicSuffixList == nil {
+			cfg.PublicSuffixList = origin.DefaultPublicSuffixList
+		}
+	}
+
+	if cfg.PreflightPassthrough && cfg.tmp.PreflightSuccessStatusCalled {
+		cfg.PreflightPassthroughDefaultStatus = true
+	}
+
+	if cfg.PreventSharedCaching {
+		cfg.PreflightCacheControl = privateDirectives
+		if cfg.ACMA != nil {
+			maxAge := "max-age=" + cfg.ACMA[0]
+			cfg.PreflightCacheControl = []string{privateDirectives[0], maxAge}
+		}
 	}
 
 	// precompute ACAM if it can be static
 	switch {
-	case !cfg.AllowCredentials && cfg.AllowAnyMethod:
+	case !cfg.AllowCredentials && cfg.AllowAnyMethod && !cfg.tmp.AssumeNoWildcardSupport:
 		cfg.ACAM = precomputedWildcard
 	case len(cfg.tmp.AllowedMethods) != 0:
 		acam := sortCombineWithComma(cfg.tmp.AllowedMethods)
 		cfg.ACAM = []string{acam}
 	}
 
+	if cfg.tmp.RespondToOptionsRequests {
+		methods := util.NewSet(http.MethodOptions)
+		for m := range safelistedMethods {
+			methods.Add(m)
+		}
+		for m := range cfg.tmp.AllowedMethods {
+			methods.Add(m)
+		}
+		cfg.Allow = []string{joinAllow(methods)}
+	}
+
 	// precompute ACAH if it can be static
 	switch {
-	case !cfg.AllowCredentials && cfg.AllowAnyRequestHeaders:
+	case !cfg.AllowCredentials && cfg.AllowAnyRequestHeaders && !cfg.tmp.AssumeNoWildcardSupport:
 		var b strings.Builder
 		b.WriteString(wildcard)
 		b.WriteByte(comma)
 		b.WriteString(byteLowercase(headerAuthorization))
 		cfg.ACAH = []string{b.String()}
+	case len(cfg.tmp.AllowedRequestHeaderPrefixes) != 0:
+		// The ACAH header must be computed from the request's ACRH header.
+		for prefix := range cfg.tmp.AllowedRequestHeaderPrefixes {
+			cfg.AllowedRequestHeaderPrefixes = append(cfg.AllowedRequestHeaderPrefixes, prefix)
+		}
+		slices.Sort(cfg.AllowedRequestHeaderPrefixes)
 	case len(cfg.tmp.AllowedRequestHeaders) != 0:
 		acah := sortCombineWithComma(cfg.tmp.AllowedRequestHeaders)
 		cfg.ACAH = []string{acah}
 	}
 
+	cfg.MethodRequestHeaders = cfg.tmp.MethodRequestHeaders
+	if cfg.EnforceRequestHeaders ||
+		len(cfg.AllowedRequestHeaderPrefixes) != 0 ||
+		len(cfg.MethodRequestHeaders) != 0 {
+		cfg.AllowedRequestHeaders = cfg.tmp.AllowedRequestHeaders
+		if cfg.AllowedRequestHeaders == nil {
+			cfg.AllowedRequestHeaders = make(util.Set[string])
+		}
+	}
+
 	// possibly overwrite precomputed ACEH (can always be static)
-	if !cfg.AllowCredentials && cfg.ExposeAllResponseHeaders {
+	switch {
+	case !cfg.AllowCredentials && cfg.ExposeAllResponseHeaders && cfg.tmp.AssumeNoWildcardSupport:
+		// In the absence of the wildcard, the ACEH header must list
+		// the names of the response headers that the handler sets.
+		cfg.ExposeResponseHeadersAutomatically = true
+	case !cfg.AllowCredentials && cfg.ExposeAllResponseHeaders:
 		cfg.ACEH = precomputedWildcard
 	}
 	cfg.tmp = nil // no longer needed; let's make it eligible to GC
 }
 
+// originSourceSpecified reports whether at least one of the options
+// that allow specific origins was used.
+func (cfg *Config) originSourceSpecified() bool {
+	return cfg.tmp.FromOriginsCalled ||
+		cfg.tmp.FromLoopbackOriginsCalled ||
+		cfg.tmp.FromSitesCalled ||
+		cfg.AllowSameSiteOrigins ||
+		cfg.tmp.FromOriginsForHostCalled ||
+		cfg.tmp.FromOriginsBetweenCalled ||
+		cfg.tmp.FromLabeledOriginsCalled
+}
+
+// allowsSingleOrigin reports whether exactly one origin is allowed,
+// in which case we don't need a corpus at all.
+func (cfg *Config) allowsSingleOrigin() bool {
+	if len(cfg.tmp.OriginPatterns) != 1 ||
+		len(cfg.tmp.ExcludedOriginPatterns) != 0 ||
+		len(cfg.tmp.HostOrigins) != 0 ||
+		len(cfg.tmp.TimedOrigins) != 0 ||
+		len(cfg.tmp.LabeledOrigins) != 0 ||
+		cfg.AllowSameSiteOrigins {
+		return false
+	}
+	for pattern := range cfg.tmp.OriginPatterns {
+		return pattern.EncompassesSingleOrigin()
+	}
+	return false
+}
+
+// buildCorpus builds a corpus from patterns.
+func (cfg *Config) buildCorpus(patterns util.Set[origin.Pattern]) *origin.Corpus {
+	corpus := new(origin.Corpus)
+	for pattern := range patterns {
+		corpus.Add(&pattern)
+	}
+	// Exclusions (if any) apply to every corpus.
+	for _, excluded := range cfg.tmp.ExcludedOriginPatterns {
+		corpus.Exclude(&excluded.pattern)
+	}
+	return corpus
+}
+
+// overlapsAllowedOrigins reports whether pattern overlaps
+// at least one of the allowed origin patterns.
+func (cfg *Config) overlapsAllowedOrigins(pattern *origin.Pattern) bool {
+	if cfg.AllowSameSiteOrigins && pattern.Scheme == schemeHTTPS {
+		// Which https origins are same site depends on the host targeted
+		// by the request; any of them may be.
+		return true
+	}
+	for allowed := range cfg.tmp.OriginPatterns {
+		if pattern.Overlaps(&allowed) {
+			return true
+		}
+	}
+	for _, ho := range cfg.tmp.HostOrigins {
+		for allowed := range ho.patterns {
+			if pattern.Overlaps(&allowed) {
+				return true
+			}
+		}
+	}
+	for _, to := range cfg.tmp.TimedOrigins {
+		for allowed := range to.patterns {
+			if pattern.Overlaps(&allowed) {
+				return true
+			}
+		}
+	}
+	for _, lo := range cfg.tmp.LabeledOrigins {
+		for allowed := range lo.patterns {
+			if pattern.Overlaps(&allowed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (cfg *Config) middleware() Middleware {
 	middleware := func(h http.Handler) http.Handler {
 		f := func(w http.ResponseWriter, r *http.Request) {
@@ -234,27 +669,35 @@ func (cfg *Config) middleware() Middleware {
 			if !found {
 				// r is _not_ a CORS request.
 				cfg.handleNonCORSRequest(w.Header(), isOptionsReq)
-				h.ServeHTTP(w, r)
+				if isOptionsReq && cfg.Allow != nil {
+					cfg.handleOptionsRequest(w, r)
+					return
+				}
+				cfg.serve(h, w, r)
 				return
 			}
 			// r is a CORS request (and possibly a CORS-preflight request);
 			// see https://fetch.spec.whatwg.org/#cors-request.
 			if !isOptionsReq {
 				// r is a non-OPTIONS CORS request.
-				cfg.handleNonPreflightCORSRequest(w, origins, isOptionsReq)
-				h.ServeHTTP(w, r)
+				r = cfg.handleNonPreflightCORSRequest(w, r, origins, isOptionsReq)
+				cfg.serve(h, w, r)
 				return
 			}
 			acrm, found := first(r.Header, headerRequestMethod)
 			if found {
 				// r is a CORS-preflight request;
 				// see https://fetch.spec.whatwg.org/#cors-preflight-request.
-				cfg.handleCORSPreflightRequest(w, r.Header, origins, acrm)
+				cfg.handleCORSPreflightRequest(h, w, r, origins, acrm)
 				return
 			}
 			// r is a non-preflight OPTIONS CORS request.
-			cfg.handleNonPreflightCORSRequest(w, origins, isOptionsReq)
-			h.ServeHTTP(w, r)
+			r = cfg.handleNonPreflightCORSRequest(w, r, origins, isOptionsReq)
+			if cfg.Allow != nil {
+				cfg.handleOptionsRequest(w, r)
+				return
+			}
+			cfg.serve(h, w, r)
 		}
 		return http.HandlerFunc(f)
 	}
@@ -265,18 +708,18 @@ func (cfg *Config) handleNonCORSRequest(respHeaders http.Header, isOptionsReq bo
 	// see https://wicg.github.io/private-network-access/#shortlinks
 	if cfg.PrivateNetworkAccessInNoCORSModeOnly {
 		if isOptionsReq {
-			fastAdd(respHeaders, headerVary, precomputedPreflightVaryValue)
+			fastAdd(respHeaders, headerVary, cfg.preflightVaryValue())
 		}
 		return
 	}
 	var varyHeaderAdded bool
 	if isOptionsReq {
-		fastAdd(respHeaders, headerVary, precomputedPreflightVaryValue)
+		fastAdd(respHeaders, headerVary, cfg.preflightVaryValue())
 		varyHeaderAdded = true
 	}
 	if cfg.ACAO == nil {
 		if !varyHeaderAdded {
-			fastAdd(respHeaders, headerVary, precomputedHeaderOrigin)
+			fastAdd(respHeaders, headerVary, cfg.originVaryValue())
 		}
 		return
 	}
@@ -297,15 +740,19 @@ func (cfg *Config) handleNonCORSRequest(respHeaders http.Header, isOptionsReq bo
 // For details about the order in which we perform the following checks,
 // see https://fetch.spec.whatwg.org/#cors-preflight-fetch, item 7.
 func (cfg *Config) handleCORSPreflightRequest(
+	h http.Handler,
 	w http.ResponseWriter,
-	reqHeaders http.Header,
+	r *http.Request,
 	origins []string, // assumed non-empty
 	acrm []string, // assumed non-empty
 ) {
 	respHeaders := w.Header()
-	fastAdd(respHeaders, headerVary, precomputedPreflightVaryValue)
-	if !cfg.processOriginForPreflight(respHeaders, origins) {
-		w.WriteHeader(http.StatusForbidden)
+	reqHeaders := r.Header
+	fastAdd(respHeaders, headerVary, cfg.preflightVaryValue())
+	r, ok := cfg.processOriginForPreflight(respHeaders, r, origins)
+	if !ok {
+		recordPreflightFailure(r, OriginNotAllowed)
+		cfg.rejectPreflight(w)
 		return
 	}
 	// At this stage, browsers fail the CORS-preflight check
@@ -318,52 +765,133 @@ func (cfg *Config) handleCORSPreflightRequest(
 	// however, for easier troubleshooting on the client side,
 	// we nonetheless respond with an ok status.
 	if !cfg.processACRPN(respHeaders, reqHeaders) {
-		w.WriteHeader(cfg.PreflightSuccessStatus)
+		recordPreflightFailure(r, PrivateNetworkAccessNotAllowed)
+		cfg.endPreflight(h, w, r)
 		return
 	}
 	if !cfg.processACRM(respHeaders, acrm) {
-		w.WriteHeader(cfg.PreflightSuccessStatus)
+		recordPreflightFailure(r, MethodNotAllowed)
+		cfg.endPreflight(h, w, r)
 		return
 	}
-	if !cfg.processACRH(respHeaders, reqHeaders) {
-		w.WriteHeader(cfg.PreflightSuccessStatus)
+	if !cfg.processACRH(respHeaders, reqHeaders, acrm[0]) {
+		recordPreflightFailure(r, RequestHeadersNotAllowed)
+		if cfg.EnforceRequestHeaders {
+			// In strict mode, we explicitly reject preflight requests
+			// for disallowed request headers.
+			cfg.rejectPreflight(w)
+			return
+		}
+		cfg.endPreflight(h, w, r)
 		return
 	}
 	if cfg.ACMA != nil {
 		respHeaders[headerMageAge] = cfg.ACMA
 	}
+	cfg.endPreflight(h, w, r)
+}
+
+// endPreflight ends the handling of CORS-preflight request r
+// by responding with PreflightSuccessStatus, unless preflight passthrough
+// is enabled, in which case h handles r (and remains responsible for the
+// status, which defaults to PreflightSuccessStatus if that option was used).
+func (cfg *Config) endPreflight(h http.Handler, w http.ResponseWriter, r *http.Request) {
+	if cfg.PreflightPassthrough {
+		var defaultStatus int
+		if cfg.PreflightPassthroughDefaultStatus {
+			defaultStatus = cfg.PreflightSuccessStatus
+		}
+		cfg.serveAdjusting(h, w, r, false, cfg.PreflightCacheControl, defaultStatus)
+		return
+	}
+	if cfg.PreflightCacheControl != nil {
+		mergeCacheControl(w.Header(), cfg.PreflightCacheControl)
+	}
 	w.WriteHeader(cfg.PreflightSuccessStatus)
 }
 
+// rejectPreflight explicitly rejects a CORS-preflight request.
+// Even if preflight passthrough is enabled, the handler doesn't get to
+// handle such requests, lest it respond with an ok status.
+func (cfg *Config) rejectPreflight(w http.ResponseWriter) {
+	if cfg.PreflightCacheControl != nil {
+		mergeCacheControl(w.Header(), cfg.PreflightCacheControl)
+	}
+	w.WriteHeader(http.StatusForbidden)
+}
+
+// handleOptionsRequest responds to a non-preflight OPTIONS request
+// on behalf of the handler; see
+// https://www.rfc-editor.org/rfc/rfc9110#name-options.
+func (cfg *Config) handleOptionsRequest(w http.ResponseWriter, r *http.Request) {
+	respHeaders := w.Header()
+	respHeaders[headerAllow] = cfg.allowFor(r)
+	if cfg.PreventSharedCaching && cfg.ACAO == nil {
+		mergeCacheControl(respHeaders, privateDirectives)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// allowFor returns the value of the Allow header in the response
+// to non-preflight OPTIONS request r. If option WithRouteMethods was used
+// and reports some valid methods for r, that value lists those methods
+// (along with OPTIONS); otherwise, it's the precomputed value.
+func (cfg *Config) allowFor(r *http.Request) []string {
+	if cfg.RouteMethods == nil {
+		return cfg.Allow
+	}
+	methods := util.NewSet(http.MethodOptions)
+	for _, m := range cfg.RouteMethods(r) {
+		// The route's methods come from outside our control;
+		// we only list well-formed method names.
+		if isValidMethod(m) {
+			methods.Add(m)
+		}
+	}
+	if len(methods) == 1 { // no route-derived methods
+		return cfg.Allow
+	}
+	return []string{joinAllow(methods)}
+}
+
+// joinAllow returns, in sorted order, the elements of methods
+// joined in a form suitable for the Allow header.
+func joinAllow(methods util.Set[string]) string {
+	return strings.Join(sortedElems(methods), ", ")
+}
+
+// processOriginForPreflight reports whether the origin of r is allowed.
+// If so, it returns r, possibly with the origin's label attached.
 func (cfg *Config) processOriginForPreflight(
 	respHeaders http.Header,
+	r *http.Request,
 	origins []string, // assumed non-empty
-) bool {
+) (*http.Request, bool) {
 	rawOrigin := origins[0]
 	o, ok := origin.Parse(rawOrigin)
 	if !ok {
-		return false
+		return r, false
 	}
 	if cfg.ACAO != nil { // by construction, guaranteed to be non-empty
 		if !cfg.AllowAnyOrigin && cfg.ACAO[0] != rawOrigin {
-			return false
+			return r, false
 		}
 		respHeaders[headerAllowOrigin] = cfg.ACAO
 		if cfg.AllowCredentials {
 			// We make no attempt to infer whether the request is credentialed.
 			respHeaders[headerAllowCredentials] = precomputedTrue
 		}
-		return true
+		return r, true
 	}
-	if !cfg.Corpus.Contains(&o) {
-		return false
+	if !cfg.allowsOrigin(&o, r) {
+		return r, false
 	}
 	respHeaders[headerAllowOrigin] = origins
 	if cfg.AllowCredentials {
 		// We make no attempt to infer whether the request is credentialed.
 		respHeaders[headerAllowCredentials] = precomputedTrue
 	}
-	return true
+	return cfg.withOriginLabel(r, &o), true
 }
 
 // About this specific check, see
@@ -383,22 +911,23 @@ func (cfg *Config) processACRPN(respHeaders, reqHeaders http.Header) bool {
 // Note: only for _non-preflight_ CORS requests
 func (cfg *Config) handleNonPreflightCORSRequest(
 	w http.ResponseWriter,
+	r *http.Request,
 	origins []string, // assumed non-empty
 	isOptionsReq bool,
-) {
+) *http.Request {
 	respHeaders := w.Header()
 	// see https://wicg.github.io/private-network-access/#shortlinks
 	if cfg.PrivateNetworkAccessInNoCORSModeOnly {
 		if isOptionsReq {
-			fastAdd(respHeaders, headerVary, precomputedPreflightVaryValue)
+			fastAdd(respHeaders, headerVary, cfg.preflightVaryValue())
 		}
-		return
+		return r
 	}
 	switch {
 	case isOptionsReq:
-		fastAdd(respHeaders, headerVary, precomputedPreflightVaryValue)
+		fastAdd(respHeaders, headerVary, cfg.preflightVaryValue())
 	case cfg.ACAO == nil:
-		fastAdd(respHeaders, headerVary, precomputedHeaderOrigin)
+		fastAdd(respHeaders, headerVary, cfg.originVaryValue())
 	}
 	if cfg.ACAO != nil {
 		// See the last paragraph in
@@ -411,11 +940,11 @@ func (cfg *Config) handleNonPreflightCORSRequest(
 		if cfg.ACEH != nil {
 			respHeaders[headerExposeHeaders] = cfg.ACEH
 		}
-		return
+		return r
 	}
 	o, ok := origin.Parse(origins[0])
-	if !ok || !cfg.Corpus.Contains(&o) {
-		return
+	if !ok || !cfg.allowsOrigin(&o, r) {
+		return r
 	}
 	respHeaders[headerAllowOrigin] = origins
 	if cfg.AllowCredentials {
@@ -425,6 +954,132 @@ func (cfg *Config) handleNonPreflightCORSRequest(
 	if cfg.ACEH != nil {
 		respHeaders[headerExposeHeaders] = cfg.ACEH
 	}
+	return cfg.withOriginLabel(r, &o)
+}
+
+// allowsOrigin reports whether o, the origin of r, is allowed.
+func (cfg *Config) allowsOrigin(o *origin.Origin, r *http.Request) bool {
+	corpus := cfg.Corpus
+	if cfg.HostCorpora != nil {
+		// Requests to hosts without a dedicated corpus
+		// fall back to the default corpus.
+		if c, found := cfg.HostCorpora[cfg.requestHost(r)]; found {
+			corpus = c
+		}
+	}
+	// Because all corpora share the same exclusions, which take precedence
+	// over all the sources of allowed origins, consulting one suffices.
+	if corpus.Excludes(o) {
+		return false
+	}
+	return corpus.Contains(o) ||
+		cfg.AllowSameSiteOrigins && cfg.isSameSite(o, r) ||
+		len(cfg.TimedCorpora) > 0 && cfg.allowsOriginNow(o) ||
+		len(cfg.LabeledCorpora) > 0 && cfg.hasLabel(o)
+}
+
+// A labeledCorpus is a corpus of origins that share some label.
+type labeledCorpus struct {
+	label  string
+	corpus *origin.Corpus
+}
+
+// labelOf returns the label of the first labeled corpus (in the order
+// in which the labels were specified) that contains o.
+func (cfg *Config) labelOf(o *origin.Origin) (string, bool) {
+	for _, lc := range cfg.LabeledCorpora {
+		if lc.corpus.Contains(o) {
+			return lc.label, true
+		}
+	}
+	return "", false
+}
+
+func (cfg *Config) hasLabel(o *origin.Origin) bool {
+	_, found := cfg.labelOf(o)
+	return found
+}
+
+// A timedCorpus is a corpus of origins that are allowed
+// only during some validity window.
+type timedCorpus struct {
+	// the zero value means no lower bound
+	notBefore time.Time
+	notAfter  time.Time
+	corpus    *origin.Corpus
+}
+
+// allowsOriginNow reports whether o is allowed by one of the timed corpora
+// whose validity window contains the current time.
+func (cfg *Config) allowsOriginNow(o *origin.Origin) bool {
+	now := cfg.Now()
+	for _, tc := range cfg.TimedCorpora {
+		if now.Before(tc.notBefore) || !now.Before(tc.notAfter) {
+			continue
+		}
+		if tc.corpus.Contains(o) {
+			return true
+		}
+	}
+	return false
+}
+
+// preflightVaryValue returns the value to add to the Vary header
+// of responses to OPTIONS requests.
+func (cfg *Config) preflightVaryValue() []string {
+	if cfg.VaryXForwardedHost {
+		return precomputedPreflightVaryValueXFH
+	}
+	return precomputedPreflightVaryValue
+}
+
+// originVaryValue returns the value to add to the Vary header
+// of responses to non-OPTIONS requests when ACAO is set dynamically.
+func (cfg *Config) originVaryValue() []string {
+	if cfg.VaryXForwardedHost {
+		return precomputedHeaderOriginXFH
+	}
+	return precomputedHeaderOrigin
+}
+
+// requestHost returns the (lowercase) host targeted by r, without any port.
+func (cfg *Config) requestHost(r *http.Request) string {
+	host := r.Host
+	if cfg.TrustXForwardedHost {
+		if xfh, found := first(r.Header, headerXForwardedHost); found {
+			// Only the first element of the list (if any) is relevant.
+			host, _, _ = strings.Cut(xfh[0], string(comma))
+			host = strings.TrimSpace(host)
+		}
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	} else {
+		// IPv6 address without port
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	}
+	return byteLowercase(host)
+}
+
+// isSameSite reports whether o is an https origin whose host has the same
+// registrable domain (a.k.a. "eTLD+1") as the host targeted by r;
+// see https://html.spec.whatwg.org/multipage/browsers.html#same-site.
+func (cfg *Config) isSameSite(o *origin.Origin, r *http.Request) bool {
+	if o.Scheme != schemeHTTPS || o.AssumeIP {
+		return false
+	}
+	host := cfg.requestHost(r)
+	if _, err := netip.ParseAddr(host); err == nil {
+		return false
+	}
+	site, ok := origin.EffectiveTLDPlusOne(cfg.PublicSuffixList, host)
+	if !ok {
+		return false
+	}
+	// o.Value, which stems from a Fetch-compliant browser,
+	// is assumed to already be lowercase.
+	rest, found := strings.CutSuffix(o.Value, site)
+	return found && (rest == "" || strings.HasSuffix(rest, "."))
 }
 
 func (cfg *Config) processACRM(
@@ -444,15 +1099,42 @@ func (cfg *Config) processACRM(
 	if !cfg.AllowAnyMethod {
 		return false
 	}
+	// Rather than blindly trusting that only browsers send CORS-preflight
+	// requests, we only echo back well-formed method names of reasonable size.
+	if len(acrm[0]) > maxACRMLen || !isValidMethod(acrm[0]) {
+		return false
+	}
 	headers[headerAllowMethods] = acrm
 	return true
 }
 
-func (cfg *Config) processACRH(respHeaders, reqHeaders http.Header) bool {
+func (cfg *Config) processACRH(respHeaders, reqHeaders http.Header, method string) bool {
 	acrh, found := first(reqHeaders, headerRequestHeaders)
 	if !found {
 		return true
 	}
+	if extra, found := cfg.MethodRequestHeaders[method]; found {
+		return cfg.processACRHForMethod(respHeaders, acrh[0], extra)
+	}
+	if cfg.DeniedRequestHeaders != nil {
+		return cfg.processACRHExcept(respHeaders, acrh[0])
+	}
+	if len(cfg.AllowedRequestHeaderPrefixes) != 0 {
+		// The ACAH header cannot be precomputed;
+		// we list exactly the (allowed) requested header names.
+		if !cfg.allowsRequestHeaders(acrh[0]) {
+			return false
+		}
+		acah, ok := sanitizeACRH(acrh[0])
+		if !ok {
+			return false
+		}
+		respHeaders[headerAllowHeaders] = acah
+		return true
+	}
+	if cfg.EnforceRequestHeaders && !cfg.allowsRequestHeaders(acrh[0]) {
+		return false
+	}
 	if cfg.ACAH != nil {
 		respHeaders[headerAllowHeaders] = cfg.ACAH
 		return true
@@ -460,13 +1142,119 @@ func (cfg *Config) processACRH(respHeaders, reqHeaders http.Header) bool {
 	if !cfg.AllowAnyRequestHeaders {
 		return false
 	}
-	// We can take a shortcut here and simply reuse the request's ACRH header.
-	// Because Fetch-compliant browsers wouldn't send a malformed ACRH header,
-	// we don't expect this shortcut to be detrimental to security.
-	respHeaders[headerAllowHeaders] = acrh
+	// Rather than blindly trusting that only browsers send CORS-preflight
+	// requests, we only echo back a sanitized version of the request's ACRH
+	// header.
+	acah, ok := sanitizeACRH(acrh[0])
+	if !ok {
+		return false
+	}
+	respHeaders[headerAllowHeaders] = acah
+	return true
+}
+
+// processACRHForMethod processes acrh, the value of the request's
+// Access-Control-Request-Headers header, when the request's
+// Access-Control-Request-Method header specifies a method for which
+// extra request headers are allowed.
+func (cfg *Config) processACRHForMethod(
+	respHeaders http.Header,
+	acrh string,
+	extra util.Set[string],
+) bool {
+	acah, ok := sanitizeACRH(acrh)
+	if !ok {
+		return false
+	}
+	// Because acah[0] is a comma-separated list of valid, byte-lowercase,
+	// and deduplicated header names, we can simply split it.
+	for _, name := range strings.Split(acah[0], string(comma)) {
+		if !extra.Contains(name) && !cfg.allowsRequestHeader(name) {
+			return false
+		}
+	}
+	respHeaders[headerAllowHeaders] = acah
+	return true
+}
+
+// processACRHExcept processes acrh, the value of the request's
+// Access-Control-Request-Headers header, when all request headers
+// but denied ones are allowed.
+func (cfg *Config) processACRHExcept(respHeaders http.Header, acrh string) bool {
+	acah, ok := sanitizeACRH(acrh)
+	if !ok {
+		return false
+	}
+	// Because acah[0] is a comma-separated list of valid, byte-lowercase,
+	// and deduplicated header names, we can simply split it.
+	for _, name := range strings.Split(acah[0], string(comma)) {
+		if cfg.DeniedRequestHeaders.Contains(name) {
+			return false
+		}
+	}
+	// Because the wildcard cannot be used in credentialed mode and
+	// doesn't cover Authorization anyway, we list the requested names.
+	respHeaders[headerAllowHeaders] = acah
 	return true
 }
 
+// sanitizeACRH parses acrh, the value of an Access-Control-Request-Headers
+// header, and returns a value suitable for the
+// Access-Control-Allow-Headers header, in which header names are
+// byte-lowercase and deduplicated. The second result is false if acrh is
+// malformed or lists too many header names, or is too long.
+func sanitizeACRH(acrh string) ([]string, bool) {
+	if len(acrh) > maxACRHLen {
+		return nil, false
+	}
+	names, ok := parseHeaderNameList(acrh)
+	if !ok || len(names) > maxACRHElems {
+		return nil, false
+	}
+	var b strings.Builder
+	b.Grow(len(acrh))
+	for i, name := range names {
+		if slices.Contains(names[:i], name) {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(comma)
+		}
+		b.WriteString(name)
+	}
+	return []string{b.String()}, true
+}
+
+// allowsRequestHeaders reports whether acrh, the value of an
+// Access-Control-Request-Headers header, is well formed and lists
+// only allowed request-header names.
+func (cfg *Config) allowsRequestHeaders(acrh string) bool {
+	names, ok := parseHeaderNameList(acrh)
+	if !ok {
+		return false
+	}
+	for _, name := range names {
+		if !cfg.allowsRequestHeader(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowsRequestHeader reports whether name (which is assumed to be
+// byte-lowercase) is the name of an allowed request header.
+func (cfg *Config) allowsRequestHeader(name string) bool {
+	if cfg.AllowedRequestHeaders.Contains(name) {
+		return true
+	}
+	for _, prefix := range cfg.AllowedRequestHeaderPrefixes {
+		if len(name) > len(prefix) && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // To mitigate malformed (incorrect or adversarial) CORS requests,
 // we drop any subsequent values after the first occurrence (if any)
 // of each request header involved in the CORS protocol.
@@ -10,7 +10,7 @@ import (
 func TestConfigSize(t *testing.T) {
 	const (
 		cacheLineSizeInBytes = 64
-		want                 = 3 * cacheLineSizeInBytes
+		want                 = 6 * cacheLineSizeInBytes
 	)
 	got := unsafe.Sizeof(internal.Config{})
 	if got != want {
@@ -2,28 +2,58 @@ package internal
 
 import (
 	"errors"
+	"fmt"
+	"io/fs"
 	"maps"
+	"net/http"
+	"net/netip"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jub0bs/fcors/internal/origin"
 	"github.com/jub0bs/fcors/internal/util"
 )
 
 const (
+	optANWS  = "AssumeNoWildcardSupport"
+	optAPS   = "AdditionalPublicSuffixes"
+	optB     = "Bundle"
+	optBA    = "BundleAnon"
 	optEARH  = "ExposeAllResponseHeaders"
+	optEO    = "ExceptOrigins"
+	optERqH  = "EnforceRequestHeaders"
 	optERH   = "ExposeResponseHeaders"
+	optERHA  = "ExposeResponseHeadersAutomatically"
 	optFAO   = "FromAnyOrigin"
+	optFLbO  = "FromLabeledOrigins"
+	optFLO   = "FromLoopbackOrigins"
 	optFO    = "FromOrigins"
+	optFOB   = "FromOriginsBetween"
+	optFOU   = "FromOriginsUntil"
+	optFOFH  = "FromOriginsForHost"
+	optFS    = "FromSites"
+	optFSSO  = "FromSameSiteOrigins"
 	optPNA   = "PrivateNetworkAccess"
+	optPP    = "PreflightPassthrough"
+	optPSC   = "PreventSharedCaching"
 	optPNANC = "PrivateNetworkAccessInNoCORSModeOnly"
 	optDTIO  = "DangerouslyTolerateInsecureOrigins"
+	optDTSAP = "DangerouslyTolerateSubdomainsWithArbitraryPorts"
 	optDTSPS = "DangerouslyTolerateSubdomainsOfPublicSuffixes"
+	optRPSL  = "ReplacePublicSuffixList"
+	optRTOR  = "RespondToOptionsRequests"
+	optTXFH  = "TrustXForwardedHost"
 	optWAM   = "WithAnyMethod"
+	optWC    = "WithClock"
 	optWARH  = "WithAnyRequestHeaders"
+	optWARHE = "WithAnyRequestHeadersExcept"
 	optWM    = "WithMethods"
 	optWMAIS = "MaxAgeInSeconds"
 	optWPSS  = "PreflightSuccessStatus"
 	optWRH   = "WithRequestHeaders"
+	optWRHFM = "WithRequestHeadersForMethod"
+	optWRTM  = "WithRouteMethods"
 )
 
 type Option interface {
@@ -64,6 +94,17 @@ type applier interface {
 }
 
 func NewMiddleware[A applier](cred bool, one A, others ...A) (Middleware, error) {
+	cfg, err := newValidConfig(cred, one, others...)
+	if err != nil {
+		return nil, err
+	}
+	cfg.precomputeStuff()
+	return cfg.middleware(), nil
+}
+
+// newValidConfig applies the specified options to a new Config and
+// validates the result.
+func newValidConfig[A applier](cred bool, one A, others ...A) (*Config, error) {
 	cfg := newConfig(cred)
 	var errs []error
 	if err := one.apply(cfg); err != nil {
@@ -80,71 +121,443 @@ func NewMiddleware[A applier](cred bool, one A, others ...A) (Middleware, error)
 	if len(errs) != 0 {
 		return nil, errors.Join(errs...)
 	}
-	cfg.precomputeStuff()
-	return cfg.middleware(), nil
+	return cfg, nil
 }
 
 func FromOrigins(one string, others ...string) Option {
-	var (
-		setOfPatterns          = make(util.Set[origin.Pattern])
-		publicSuffixes         []string
-		insecureOriginPatterns []string
-		nonWildcardOrigin      string
-	)
-	processOnePattern := func(raw string) error {
-		pattern, err := origin.ParsePattern(raw)
-		if err != nil {
-			return err
+	f := func(cfg *Config) error {
+		var (
+			allowed hostOrigins
+			errs    []error
+		)
+		if err := allowed.add(one); err != nil {
+			errs = append(errs, err)
+		}
+		for _, pattern := range others {
+			if err := allowed.add(pattern); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if err := cfg.recordUse(optFO); err != nil {
+			errs = append(errs, err)
+		}
+		cfg.tmp.FromOriginsCalled = true
+		cfg.tmp.InsecureOriginPatterns = append(cfg.tmp.InsecureOriginPatterns, allowed.insecureOriginPatterns...)
+		cfg.tmp.SubdomainPatterns = append(cfg.tmp.SubdomainPatterns, allowed.subdomainPatterns...)
+		cfg.tmp.SubdomainsAndPortsPatterns = append(cfg.tmp.SubdomainsAndPortsPatterns, allowed.subdomainsAndPortsPatterns...)
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		if cfg.tmp.OriginPatterns == nil {
+			cfg.tmp.OriginPatterns = allowed.patterns
+		} else {
+			maps.Copy(cfg.tmp.OriginPatterns, allowed.patterns)
+		}
+		return nil
+	}
+	return option(f)
+}
+
+// A hostOrigins represents the origin patterns specified in option
+// FromOrigins, (for a given host) in option FromOriginsForHost, or
+// (for a given label) in option FromLabeledOrigins,
+// along with the subsets of them that need checking during validation.
+type hostOrigins struct {
+	// host is empty for options other than FromOriginsForHost.
+	host string
+	// label is empty for options other than FromLabeledOrigins.
+	label                      string
+	patterns                   util.Set[origin.Pattern]
+	subdomainPatterns          []rawPattern
+	subdomainsAndPortsPatterns []string
+	insecureOriginPatterns     []string
+}
+
+// errorMsgSuffix returns a suffix that names ho's host or label (if any)
+// for error messages about ho's origin patterns.
+func (ho *hostOrigins) errorMsgSuffix() string {
+	switch {
+	case ho.host != "":
+		return fmt.Sprintf(" (for host %q)", ho.host)
+	case ho.label != "":
+		return fmt.Sprintf(" (for label %q)", ho.label)
+	default:
+		return ""
+	}
+}
+
+// A timedOrigins represents the origin patterns specified in option
+// FromOriginsUntil or FromOriginsBetween, along with their validity window.
+type timedOrigins struct {
+	hostOrigins
+	// the zero value means no lower bound
+	notBefore time.Time
+	notAfter  time.Time
+}
+
+func (ho *hostOrigins) add(raw string) error {
+	pattern, err := origin.ParsePattern(raw)
+	if err != nil {
+		return err
+	}
+	if pattern.IsDeemedInsecure() {
+		ho.insecureOriginPatterns = append(ho.insecureOriginPatterns, raw)
+	}
+	if pattern.HasArbitrarySubdomainsAndPorts() {
+		ho.subdomainsAndPortsPatterns = append(ho.subdomainsAndPortsPatterns, raw)
+	}
+	if pattern.Kind == origin.PatternKindSubdomains {
+		// Whether the pattern's host is a public suffix depends on
+		// the public-suffix list in effect, which may be configured
+		// by an option applied after this one; therefore, we defer
+		// that check to validation.
+		sp := rawPattern{
+			raw:     raw,
+			pattern: *pattern,
+		}
+		ho.subdomainPatterns = append(ho.subdomainPatterns, sp)
+	}
+	if ho.patterns == nil {
+		ho.patterns = make(util.Set[origin.Pattern])
+	}
+	ho.patterns.Add(*pattern)
+	return nil
+}
+
+func FromOriginsForHost(host string, one string, others ...string) Option {
+	f := func(cfg *Config) error {
+		var errs []error
+		normalizedHost, ok := normalizeHost(host)
+		if !ok {
+			const tmpl = "invalid host %q in option " + optFOFH
+			errs = append(errs, util.Errorf(tmpl, host))
+		}
+		allowed := hostOrigins{
+			host: normalizedHost,
+		}
+		addOne := func(raw string) {
+			if err := allowed.add(raw); err != nil {
+				const tmpl = "%w (for host %q)"
+				errs = append(errs, fmt.Errorf(tmpl, err, host))
+			}
+		}
+		addOne(one)
+		for _, raw := range others {
+			addOne(raw)
+		}
+		for _, ho := range cfg.tmp.HostOrigins {
+			if ho.host == allowed.host {
+				const tmpl = "option " + optFOFH + " used multiple times for host %q"
+				errs = append(errs, util.Errorf(tmpl, host))
+				break
+			}
+		}
+		cfg.tmp.FromOriginsForHostCalled = true
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		cfg.tmp.HostOrigins = append(cfg.tmp.HostOrigins, allowed)
+		return nil
+	}
+	return option(f)
+}
+
+// normalizeHost parses host as a domain (in ASCII or Unicode form)
+// or an IP address, without any port, and returns it in the form
+// that requestHost would produce.
+func normalizeHost(host string) (string, bool) {
+	if ip, err := netip.ParseAddr(host); err == nil && ip.Is6() {
+		// IPv6 addresses must be bracketed in origins.
+		host = "[" + host + "]"
+	}
+	pattern, err := origin.ParsePattern("http://" + byteLowercase(host))
+	if err != nil ||
+		pattern.Kind == origin.PatternKindSubdomains ||
+		pattern.IsIPPrefix() ||
+		pattern.Port != 0 {
+		return "", false
+	}
+	return pattern.Value, true
+}
+
+func FromLabeledOrigins(label string, one string, others ...string) Option {
+	f := func(cfg *Config) error {
+		allowed := hostOrigins{
+			label: label,
+		}
+		var errs []error
+		if !isValidLabel(label) {
+			const tmpl = "invalid label %q in option " + optFLbO
+			errs = append(errs, util.Errorf(tmpl, label))
+		}
+		addOne := func(raw string) {
+			if err := allowed.add(raw); err != nil {
+				const tmpl = "%w (for label %q)"
+				errs = append(errs, fmt.Errorf(tmpl, err, label))
+			}
+		}
+		addOne(one)
+		for _, raw := range others {
+			addOne(raw)
+		}
+		for _, lo := range cfg.tmp.LabeledOrigins {
+			if lo.label == label {
+				const tmpl = "option " + optFLbO + " used multiple times for label %q"
+				errs = append(errs, util.Errorf(tmpl, label))
+				break
+			}
+		}
+		cfg.tmp.FromLabeledOriginsCalled = true
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		cfg.tmp.LabeledOrigins = append(cfg.tmp.LabeledOrigins, allowed)
+		return nil
+	}
+	return option(f)
+}
+
+// isValidLabel reports whether label is a non-empty sequence of
+// at most 64 visible ASCII characters.
+func isValidLabel(label string) bool {
+	const maxLabelLen = 64
+	if label == "" || len(label) > maxLabelLen {
+		return false
+	}
+	for i := 0; i < len(label); i++ {
+		if label[i] <= ' ' || label[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func FromOriginsUntil(notAfter time.Time, one string, others ...string) Option {
+	return fromOriginsBetween(optFOU, time.Time{}, notAfter, one, others...)
+}
+
+func FromOriginsBetween(notBefore, notAfter time.Time, one string, others ...string) Option {
+	return fromOriginsBetween(optFOB, notBefore, notAfter, one, others...)
+}
+
+func fromOriginsBetween(
+	optName string,
+	notBefore time.Time,
+	notAfter time.Time,
+	one string,
+	others ...string,
+) Option {
+	f := func(cfg *Config) error {
+		allowed := timedOrigins{
+			notBefore: notBefore,
+			notAfter:  notAfter,
 		}
-		if pattern.IsDeemedInsecure() {
-			insecureOriginPatterns = append(insecureOriginPatterns, raw)
+		var errs []error
+		if !notBefore.Before(notAfter) {
+			const tmpl = "empty validity window in option %s: %s is not before %s"
+			start := notBefore.Format(time.RFC3339)
+			end := notAfter.Format(time.RFC3339)
+			errs = append(errs, util.Errorf(tmpl, optName, start, end))
 		}
-		if pattern.Kind != origin.PatternKindSubdomains && nonWildcardOrigin == "" {
-			nonWildcardOrigin = raw
+		if err := allowed.add(one); err != nil {
+			errs = append(errs, err)
 		}
-		if pattern.Kind == origin.PatternKindSubdomains {
-			if _, isEffectiveTLD := pattern.HostIsEffectiveTLD(); isEffectiveTLD {
-				publicSuffixes = append(publicSuffixes, raw)
+		for _, raw := range others {
+			if err := allowed.add(raw); err != nil {
+				errs = append(errs, err)
 			}
 		}
-		setOfPatterns.Add(*pattern)
+		cfg.tmp.FromOriginsBetweenCalled = true
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		cfg.tmp.TimedOrigins = append(cfg.tmp.TimedOrigins, allowed)
 		return nil
 	}
+	return option(f)
+}
+
+func WithClock(now func() time.Time) Option {
 	f := func(cfg *Config) error {
 		var errs []error
-		if err := processOnePattern(one); err != nil {
+		if now == nil {
+			errs = append(errs, util.NewError("nil clock in option "+optWC))
+		}
+		if cfg.tmp.WithClockCalled {
+			err := util.NewError("option " + optWC + " used multiple times")
 			errs = append(errs, err)
 		}
-		for _, pattern := range others {
-			if err := processOnePattern(pattern); err != nil {
+		cfg.tmp.WithClockCalled = true
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		cfg.Now = now
+		return nil
+	}
+	return option(f)
+}
+
+func FromSites(one string, others ...string) Option {
+	f := func(cfg *Config) error {
+		setOfPatterns := make(util.Set[origin.Pattern])
+		var errs []error
+		processOneSite := func(site string) {
+			apex, subdomains, err := parseSite(site)
+			if err != nil {
 				errs = append(errs, err)
+				return
 			}
+			setOfPatterns.Add(*apex)
+			setOfPatterns.Add(*subdomains)
+			// As in FromOrigins, the public-suffix check is deferred
+			// to validation.
+			sp := rawPattern{
+				raw:     subdomains.String(),
+				pattern: *subdomains,
+			}
+			cfg.tmp.SubdomainPatterns = append(cfg.tmp.SubdomainPatterns, sp)
+		}
+		processOneSite(one)
+		for _, site := range others {
+			processOneSite(site)
 		}
-		if cfg.tmp.FromOriginsCalled {
-			err := util.NewError("option " + optFO + " used multiple times")
+		if cfg.tmp.FromSitesCalled {
+			err := util.NewError("option " + optFS + " used multiple times")
 			errs = append(errs, err)
 		}
-		cfg.tmp.FromOriginsCalled = true
-		cfg.tmp.InsecureOriginPatterns = insecureOriginPatterns
-		cfg.tmp.PublicSuffixes = publicSuffixes
+		cfg.tmp.FromSitesCalled = true
 		if len(errs) != 0 {
 			return errors.Join(errs...)
 		}
-		if len(setOfPatterns) == 1 && nonWildcardOrigin != "" {
-			// special case in which we don't need a corpus at all
-			cfg.tmp.SingleNonWildcardOrigin = nonWildcardOrigin
-			return nil
+		if cfg.tmp.OriginPatterns == nil {
+			cfg.tmp.OriginPatterns = setOfPatterns
+		} else {
+			maps.Copy(cfg.tmp.OriginPatterns, setOfPatterns)
 		}
-		corpus := make(origin.Corpus)
-		for pattern := range setOfPatterns {
-			corpus.Add(&pattern)
+		return nil
+	}
+	return option(f)
+}
+
+// parseSite parses site as a domain and returns two origin patterns:
+// one for the domain itself and one for all of its subdomains,
+// both on scheme https and the default port.
+func parseSite(site string) (*origin.Pattern, *origin.Pattern, error) {
+	const scheme = "https://"
+	apex, err := origin.ParsePattern(scheme + site)
+	if err != nil || apex.Kind != origin.PatternKindDomain || apex.Port != 0 {
+		return nil, nil, util.Errorf("invalid site %q", site)
+	}
+	// The apex's host is in ASCII form, even if site was specified
+	// in Unicode form.
+	subdomains, err := origin.ParsePattern(scheme + "*." + apex.Value)
+	if err != nil {
+		return nil, nil, util.Errorf("invalid site %q", site)
+	}
+	return apex, subdomains, nil
+}
+
+func FromSameSiteOrigins() Option {
+	f := func(cfg *Config) error {
+		if cfg.AllowSameSiteOrigins {
+			return util.NewError("option " + optFSSO + " used multiple times")
 		}
-		cfg.Corpus = corpus
+		cfg.AllowSameSiteOrigins = true
 		return nil
 	}
 	return option(f)
 }
 
+func FromLoopbackOrigins() Option {
+	f := func(cfg *Config) error {
+		var errs []error
+		if !devModeEnabled() {
+			const msg = "option " + optFLO + " requires either build tag " +
+				devBuildTag + " or environment variable " + devEnvVar + "=true"
+			errs = append(errs, util.NewError(msg))
+		}
+		if cfg.tmp.FromLoopbackOriginsCalled {
+			err := util.NewError("option " + optFLO + " used multiple times")
+			errs = append(errs, err)
+		}
+		cfg.tmp.FromLoopbackOriginsCalled = true
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		if cfg.tmp.OriginPatterns == nil {
+			cfg.tmp.OriginPatterns = make(util.Set[origin.Pattern])
+		}
+		for _, pattern := range origin.LoopbackPatterns() {
+			cfg.tmp.OriginPatterns.Add(pattern)
+		}
+		return nil
+	}
+	return option(f)
+}
+
+func ExceptOrigins(one string, others ...string) Option {
+	f := func(cfg *Config) error {
+		var errs []error
+		processOnePattern := func(raw string) {
+			pattern, err := origin.ParsePattern(raw)
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			excluded := rawPattern{
+				raw:     raw,
+				pattern: *pattern,
+			}
+			cfg.tmp.ExcludedOriginPatterns = append(cfg.tmp.ExcludedOriginPatterns, excluded)
+		}
+		processOnePattern(one)
+		for _, raw := range others {
+			processOnePattern(raw)
+		}
+		if cfg.tmp.ExceptOriginsCalled {
+			err := util.NewError("option " + optEO + " used multiple times")
+			errs = append(errs, err)
+		}
+		cfg.tmp.ExceptOriginsCalled = true
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		return nil
+	}
+	return option(f)
+}
+
+func AdditionalPublicSuffixes(fsys fs.FS, name string) Option {
+	f := func(cfg *Config) error {
+		var errs []error
+		list, err := readPublicSuffixList(fsys, name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if cfg.tmp.AdditionalPublicSuffixesCalled {
+			err := util.NewError("option " + optAPS + " used multiple times")
+			errs = append(errs, err)
+		}
+		cfg.tmp.AdditionalPublicSuffixesCalled = true
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		// Additional public suffixes can only make the public-suffix check
+		// stricter, never laxer.
+		cfg.tmp.PublicSuffixList = origin.Union(origin.DefaultPublicSuffixList, list)
+		return nil
+	}
+	return option(f)
+}
+
+func readPublicSuffixList(fsys fs.FS, name string) (*origin.SuffixList, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, util.Errorf("failed to read public-suffix list %q: %v", name, err)
+	}
+	return origin.ParsePublicSuffixList(name, data)
+}
+
 func FromAnyOrigin() OptionAnon {
 	f := func(cfg *Config) error {
 		if cfg.AllowAnyOrigin {
@@ -173,15 +586,18 @@ func WithMethods(one string, others ...string) Option {
 		// (see https://stackoverflow.com/a/71429784/2541573),
 		// let's remove them silently.
 		maps.DeleteFunc(allowedMethods, isSafelisted)
-		if cfg.tmp.WithMethodsCalled {
-			err := util.NewError("option " + optWM + " used multiple times")
+		if err := cfg.recordUse(optWM); err != nil {
 			errs = append(errs, err)
 		}
 		cfg.tmp.WithMethodsCalled = true
 		if len(errs) != 0 {
 			return errors.Join(errs...)
 		}
-		cfg.tmp.AllowedMethods = allowedMethods
+		if cfg.tmp.AllowedMethods == nil {
+			cfg.tmp.AllowedMethods = allowedMethods
+		} else {
+			maps.Copy(cfg.tmp.AllowedMethods, allowedMethods)
+		}
 		return nil
 	}
 	return option(f)
@@ -220,29 +636,66 @@ func WithRequestHeaders(one string, others ...string) Option {
 	f := func(cfg *Config) error {
 		sizeHint := 1 + len(others) // there may be dupes, but that's the user's fault
 		allowedHeaders := make(util.Set[string], sizeHint)
+		prefixes := make(util.Set[string])
 		var errs []error
-		if err := processOneRequestHeader(one, allowedHeaders); err != nil {
-			errs = append(errs, err)
-		}
-		for _, name := range others {
-			if err := processOneRequestHeader(name, allowedHeaders); err != nil {
+		processOne := func(name string) {
+			var err error
+			if prefix, ok := strings.CutSuffix(name, wildcard); ok && prefix != "" {
+				err = processOneRequestHeaderPrefix(name, prefix, prefixes)
+			} else {
+				err = processOneRequestHeader(name, allowedHeaders)
+			}
+			if err != nil {
 				errs = append(errs, err)
 			}
 		}
-		if cfg.tmp.WithRequestHeadersCalled {
-			err := util.NewError("option " + optWRH + " used multiple times")
+		processOne(one)
+		for _, name := range others {
+			processOne(name)
+		}
+		if err := cfg.recordUse(optWRH); err != nil {
 			errs = append(errs, err)
 		}
 		cfg.tmp.WithRequestHeadersCalled = true
 		if len(errs) != 0 {
 			return errors.Join(errs...)
 		}
-		cfg.tmp.AllowedRequestHeaders = allowedHeaders
+		if cfg.tmp.AllowedRequestHeaders == nil {
+			cfg.tmp.AllowedRequestHeaders = allowedHeaders
+		} else {
+			maps.Copy(cfg.tmp.AllowedRequestHeaders, allowedHeaders)
+		}
+		if len(prefixes) != 0 {
+			if cfg.tmp.AllowedRequestHeaderPrefixes == nil {
+				cfg.tmp.AllowedRequestHeaderPrefixes = prefixes
+			} else {
+				maps.Copy(cfg.tmp.AllowedRequestHeaderPrefixes, prefixes)
+			}
+		}
 		return nil
 	}
 	return option(f)
 }
 
+// processOneRequestHeaderPrefix processes pattern, a request-header name
+// pattern (e.g. "x-amz-*") made of prefix followed by a wildcard character.
+func processOneRequestHeaderPrefix(pattern, prefix string, prefixes util.Set[string]) error {
+	if !isValidHeaderName(prefix) || strings.Contains(prefix, wildcard) {
+		return util.Errorf("invalid request-header name pattern %q", pattern)
+	}
+	prefix = byteLowercase(prefix)
+	if encompassesForbiddenRequestHeaderNames(prefix) {
+		return util.Errorf("forbidden request-header name pattern %q", pattern)
+	}
+	for name := range prohibitedRequestHeaderNames {
+		if name != wildcard && strings.HasPrefix(name, prefix) {
+			return util.Errorf("prohibited request-header name pattern %q", pattern)
+		}
+	}
+	prefixes.Add(prefix)
+	return nil
+}
+
 func processOneRequestHeader(name string, allowedHeaders util.Set[string]) error {
 	if !isValidHeaderName(name) {
 		return util.Errorf("invalid request-header name %q", name)
@@ -273,6 +726,83 @@ func WithAnyRequestHeaders() Option {
 	return option(f)
 }
 
+func WithRequestHeadersForMethod(method string, one string, others ...string) Option {
+	f := func(cfg *Config) error {
+		var errs []error
+		methods := make(util.Set[string], 1)
+		if err := processOneMethod(method, methods); err != nil {
+			const tmpl = "%w in option " + optWRHFM
+			errs = append(errs, fmt.Errorf(tmpl, err))
+		}
+		sizeHint := 1 + len(others) // there may be dupes, but that's the user's fault
+		allowedHeaders := make(util.Set[string], sizeHint)
+		addOne := func(name string) {
+			if err := processOneRequestHeader(name, allowedHeaders); err != nil {
+				const tmpl = "%w (for method %q)"
+				errs = append(errs, fmt.Errorf(tmpl, err, method))
+			}
+		}
+		addOne(one)
+		for _, name := range others {
+			addOne(name)
+		}
+		if _, found := cfg.tmp.MethodRequestHeaders[method]; found {
+			const tmpl = "option " + optWRHFM + " used multiple times for method %q"
+			errs = append(errs, util.Errorf(tmpl, method))
+		}
+		cfg.tmp.WithRequestHeadersForMethodCalled = true
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		if cfg.tmp.MethodRequestHeaders == nil {
+			cfg.tmp.MethodRequestHeaders = make(map[string]util.Set[string])
+		}
+		cfg.tmp.MethodRequestHeaders[method] = allowedHeaders
+		return nil
+	}
+	return option(f)
+}
+
+func WithAnyRequestHeadersExcept(one string, others ...string) Option {
+	f := func(cfg *Config) error {
+		sizeHint := 1 + len(others) // there may be dupes, but that's the user's fault
+		deniedHeaders := make(util.Set[string], sizeHint)
+		var errs []error
+		processOne := func(name string) {
+			if !isValidHeaderName(name) || name == wildcard {
+				errs = append(errs, util.Errorf("invalid request-header name %q", name))
+				return
+			}
+			deniedHeaders.Add(byteLowercase(name))
+		}
+		processOne(one)
+		for _, name := range others {
+			processOne(name)
+		}
+		if cfg.DeniedRequestHeaders != nil {
+			err := util.NewError("option " + optWARHE + " used multiple times")
+			errs = append(errs, err)
+		}
+		cfg.DeniedRequestHeaders = deniedHeaders
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		return nil
+	}
+	return option(f)
+}
+
+func EnforceRequestHeaders() Option {
+	f := func(cfg *Config) error {
+		if cfg.EnforceRequestHeaders {
+			return util.NewError("option " + optERqH + " used multiple times")
+		}
+		cfg.EnforceRequestHeaders = true
+		return nil
+	}
+	return option(f)
+}
+
 func MaxAgeInSeconds(delta uint) Option {
 	// Current upper bounds:
 	//  - Firefox:         86400 (24h)
@@ -287,14 +817,13 @@ func MaxAgeInSeconds(delta uint) Option {
 			err := util.Errorf(tmpl, delta, upperBound)
 			errs = append(errs, err)
 		}
-		if cfg.tmp.MaxAgeInSecondsCalled {
-			err := util.NewError("option " + optWMAIS + " used multiple times")
+		if err := cfg.recordScalarUse(optWMAIS, delta); err != nil {
 			errs = append(errs, err)
 		}
-		cfg.tmp.MaxAgeInSecondsCalled = true
 		if len(errs) != 0 {
 			return errors.Join(errs...)
 		}
+		cfg.tmp.MaxAgeInSeconds = delta
 		const base = 10
 		cfg.ACMA = []string{strconv.FormatUint(uint64(delta), base)}
 		return nil
@@ -315,15 +844,19 @@ func ExposeResponseHeaders(one string, others ...string) Option {
 				errs = append(errs, err)
 			}
 		}
-		if cfg.tmp.ExposeResponseHeadersCalled {
-			err := util.NewError("option " + optERH + " used multiple times")
+		if err := cfg.recordUse(optERH); err != nil {
 			errs = append(errs, err)
 		}
 		cfg.tmp.ExposeResponseHeadersCalled = true
 		if len(errs) != 0 {
 			return errors.Join(errs...)
 		}
-		cfg.ACEH = []string{sortCombineWithComma(exposedHeaders)}
+		if cfg.tmp.ExposedResponseHeaders == nil {
+			cfg.tmp.ExposedResponseHeaders = exposedHeaders
+		} else {
+			maps.Copy(cfg.tmp.ExposedResponseHeaders, exposedHeaders)
+		}
+		cfg.ACEH = []string{sortCombineWithComma(cfg.tmp.ExposedResponseHeaders)}
 		return nil
 	}
 	return option(f)
@@ -348,6 +881,17 @@ func processOneResponseHeader(name string, exposedHeaders util.Set[string]) erro
 	return nil
 }
 
+func ExposeResponseHeadersAutomatically() Option {
+	f := func(cfg *Config) error {
+		if cfg.ExposeResponseHeadersAutomatically {
+			return util.NewError("option " + optERHA + " used multiple times")
+		}
+		cfg.ExposeResponseHeadersAutomatically = true
+		return nil
+	}
+	return option(f)
+}
+
 func ExposeAllResponseHeaders() OptionAnon {
 	f := func(cfg *Config) error {
 		if cfg.ExposeAllResponseHeaders {
@@ -359,6 +903,17 @@ func ExposeAllResponseHeaders() OptionAnon {
 	return optionAnon(f)
 }
 
+func AssumeNoWildcardSupport() OptionAnon {
+	f := func(cfg *Config) error {
+		if cfg.tmp.AssumeNoWildcardSupport {
+			return util.NewError("option " + optANWS + " used multiple times")
+		}
+		cfg.tmp.AssumeNoWildcardSupport = true
+		return nil
+	}
+	return optionAnon(f)
+}
+
 func PreflightSuccessStatus(status uint) Option {
 	f := func(cfg *Config) error {
 		var errs []error
@@ -367,11 +922,10 @@ func PreflightSuccessStatus(status uint) Option {
 			const tmpl = "specified status %d outside the 2xx range"
 			errs = append(errs, util.Errorf(tmpl, status))
 		}
-		if cfg.tmp.CustomPreflightSuccessStatus {
-			err := util.NewError("option " + optWPSS + " used multiple times")
+		if err := cfg.recordScalarUse(optWPSS, status); err != nil {
 			errs = append(errs, err)
 		}
-		cfg.tmp.CustomPreflightSuccessStatus = true
+		cfg.tmp.PreflightSuccessStatusCalled = true
 		if len(errs) != 0 {
 			return errors.Join(errs...)
 		}
@@ -382,6 +936,59 @@ func PreflightSuccessStatus(status uint) Option {
 	return option(f)
 }
 
+func PreflightPassthrough() Option {
+	f := func(cfg *Config) error {
+		if cfg.PreflightPassthrough {
+			return util.NewError("option " + optPP + " used multiple times")
+		}
+		cfg.PreflightPassthrough = true
+		return nil
+	}
+	return option(f)
+}
+
+func PreventSharedCaching() Option {
+	f := func(cfg *Config) error {
+		if cfg.PreventSharedCaching {
+			return util.NewError("option " + optPSC + " used multiple times")
+		}
+		cfg.PreventSharedCaching = true
+		return nil
+	}
+	return option(f)
+}
+
+func RespondToOptionsRequests() Option {
+	f := func(cfg *Config) error {
+		if cfg.tmp.RespondToOptionsRequests {
+			return util.NewError("option " + optRTOR + " used multiple times")
+		}
+		cfg.tmp.RespondToOptionsRequests = true
+		return nil
+	}
+	return option(f)
+}
+
+func WithRouteMethods(methods func(*http.Request) []string) Option {
+	f := func(cfg *Config) error {
+		var errs []error
+		if methods == nil {
+			errs = append(errs, util.NewError("nil function in option "+optWRTM))
+		}
+		if cfg.tmp.WithRouteMethodsCalled {
+			err := util.NewError("option " + optWRTM + " used multiple times")
+			errs = append(errs, err)
+		}
+		cfg.tmp.WithRouteMethodsCalled = true
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		cfg.RouteMethods = methods
+		return nil
+	}
+	return option(f)
+}
+
 func PrivateNetworkAccess() Option {
 	// blanket policy that applies to all origins
 	// see https://github.com/WICG/private-network-access/issues/84
@@ -427,3 +1034,46 @@ func DangerouslyTolerateSubdomainsOfPublicSuffixes() Option {
 	}
 	return option(f)
 }
+
+func DangerouslyTolerateSubdomainsWithArbitraryPorts() Option {
+	f := func(cfg *Config) error {
+		if cfg.tmp.DangerouslyTolerateSubdomainsWithArbitraryPorts {
+			return util.NewErrorRisky("option " + optDTSAP + " used multiple times")
+		}
+		cfg.tmp.DangerouslyTolerateSubdomainsWithArbitraryPorts = true
+		return nil
+	}
+	return option(f)
+}
+
+func ReplacePublicSuffixList(fsys fs.FS, name string) Option {
+	f := func(cfg *Config) error {
+		var errs []error
+		list, err := readPublicSuffixList(fsys, name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if cfg.tmp.ReplacePublicSuffixListCalled {
+			err := util.NewErrorRisky("option " + optRPSL + " used multiple times")
+			errs = append(errs, err)
+		}
+		cfg.tmp.ReplacePublicSuffixListCalled = true
+		if len(errs) != 0 {
+			return errors.Join(errs...)
+		}
+		cfg.tmp.PublicSuffixList = list
+		return nil
+	}
+	return option(f)
+}
+
+func TrustXForwardedHost() Option {
+	f := func(cfg *Config) error {
+		if cfg.TrustXForwardedHost {
+			return util.NewErrorRisky("option " + optTXFH + " used multiple times")
+		}
+		cfg.TrustXForwardedHost = true
+		return nil
+	}
+	return option(f)
+}
@@ -37,3 +37,31 @@ func TestCredMethod(_ *testing.T) {
 	var opt option
 	opt.cred()
 }
+
+func TestNormalizeHost(t *testing.T) {
+	cases := []struct {
+		host string
+		want string
+		ok   bool
+	}{
+		{host: "API.example.com", want: "api.example.com", ok: true},
+		{host: "127.0.0.1", want: "127.0.0.1", ok: true},
+		{host: "::1", want: "::1", ok: true},
+		{host: "[::1]", want: "::1", ok: true},
+		{host: "[2001:DB8::1]", want: "2001:db8::1", ok: true},
+		{host: "2001:db8::1", want: "2001:db8::1", ok: true},
+		{host: "2001:db8:0:0:0:0:0:1"},
+		{host: "fe80::1%eth0"},
+		{host: "example.com:8080"},
+		{host: "[::1]:8080"},
+		{host: "*.example.com"},
+		{host: "10.0.0.0/8"},
+	}
+	for _, c := range cases {
+		got, ok := normalizeHost(c.host)
+		if got != c.want || ok != c.ok {
+			const tmpl = "normalizeHost(%q): got %q, %t; want %q, %t"
+			t.Errorf(tmpl, c.host, got, ok, c.want, c.ok)
+		}
+	}
+}
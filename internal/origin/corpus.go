@@ -1,20 +1,88 @@
 package origin
 
 import (
+	"net/netip"
+
 	"github.com/jub0bs/fcors/internal/radix"
 )
 
 // A Corpus represents a set of allowed (tuple) [Web origins].
-// The keys in this map correspond to origin schemes.
-type Corpus map[string]radix.Tree
+// The zero value of a Corpus is an empty corpus.
+type Corpus struct {
+	// trees maps origin schemes to radix trees of allowed hosts.
+	trees map[string]radix.Tree
+	// prefixes maps origin schemes to allowed IP prefixes.
+	prefixes map[string][]prefixPattern
+	// excluded (if non-nil) contains origins that are not allowed,
+	// even though c's trees or prefixes may encompass them.
+	excluded *Corpus
+}
+
+// A prefixPattern represents an IP prefix and a port number.
+type prefixPattern struct {
+	prefix netip.Prefix
+	// port follows the same conventions as Pattern.Port.
+	port int
+}
 
-func (c Corpus) Add(pattern *Pattern) {
-	tree := c[pattern.Scheme]
+func (c *Corpus) Add(pattern *Pattern) {
+	if prefix, ok := pattern.ipPrefix(); ok {
+		if c.prefixes == nil {
+			c.prefixes = make(map[string][]prefixPattern)
+		}
+		pp := prefixPattern{
+			prefix: prefix,
+			port:   pattern.Port,
+		}
+		c.prefixes[pattern.Scheme] = append(c.prefixes[pattern.Scheme], pp)
+		return
+	}
+	if c.trees == nil {
+		c.trees = make(map[string]radix.Tree)
+	}
+	tree := c.trees[pattern.Scheme]
 	tree.Insert(pattern.Value, pattern.Port)
-	c[pattern.Scheme] = tree
+	c.trees[pattern.Scheme] = tree
+}
+
+// Exclude excludes the origins encompassed by pattern from c.
+// Exclusions take precedence over inclusions, regardless of the order
+// in which [Corpus.Add] and [Corpus.Exclude] are called.
+func (c *Corpus) Exclude(pattern *Pattern) {
+	if c.excluded == nil {
+		c.excluded = new(Corpus)
+	}
+	c.excluded.Add(pattern)
+}
+
+// Excludes reports whether o is among the origins excluded from c
+// by [Corpus.Exclude].
+func (c *Corpus) Excludes(o *Origin) bool {
+	return c.excluded != nil && c.excluded.Contains(o)
 }
 
-func (c Corpus) Contains(o *Origin) bool {
-	tree, found := c[o.Scheme]
-	return found && tree.Contains(o.Value, o.Port)
+func (c *Corpus) Contains(o *Origin) bool {
+	if c.Excludes(o) {
+		return false
+	}
+	tree, found := c.trees[o.Scheme]
+	if found && tree.Contains(o.Value, o.Port) {
+		return true
+	}
+	prefixes := c.prefixes[o.Scheme]
+	if len(prefixes) == 0 || !o.AssumeIP {
+		return false
+	}
+	ip, err := netip.ParseAddr(o.Value)
+	// Browsers serialize IP addresses in canonical form;
+	// anything else is suspicious.
+	if err != nil || ip.Zone() != "" || ip.Is4In6() || ip.String() != o.Value {
+		return false
+	}
+	for _, pp := range prefixes {
+		if pp.prefix.Contains(ip) && (pp.port == anyPort || pp.port == o.Port) {
+			return true
+		}
+	}
+	return false
 }
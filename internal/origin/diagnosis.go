@@ -0,0 +1,79 @@
+package origin
+
+import "strings"
+
+// diagnoses of common mistakes in origin patterns
+const (
+	diagMissingScheme = "missing scheme"
+	diagTrailingSlash = "trailing slash"
+	diagPath          = "path"
+	diagQueryFragment = "query or fragment"
+	diagUppercase     = "uppercase letters"
+)
+
+// diagnose attempts to identify what makes s, which is assumed to be
+// an invalid origin pattern, invalid. If it succeeds, it returns a
+// description of the problem(s) and the valid origin pattern that was
+// likely intended; otherwise, it returns two empty strings.
+//
+// The following mistakes are recognized (in any combination):
+//
+//   - a missing scheme, as in "example.com";
+//   - a trailing slash, as in "https://example.com/";
+//   - a path, query, or fragment, as in "https://example.com/api";
+//   - uppercase letters, as in "HTTPS://Example.com".
+func diagnose(s string) (diagnosis, suggestion string) {
+	var diags []string
+	candidate := s
+	if !strings.Contains(candidate, schemeHostSep) {
+		diags = append(diags, diagMissingScheme)
+		candidate = schemeHTTPS + schemeHostSep + candidate
+	}
+	if trimmed, diag, ok := trimPath(candidate); ok {
+		diags = append(diags, diag)
+		candidate = trimmed
+	}
+	if lower := strings.ToLower(candidate); lower != candidate {
+		diags = append(diags, diagUppercase)
+		candidate = lower
+	}
+	if len(diags) == 0 {
+		return "", ""
+	}
+	if _, err := parsePattern(candidate); err != nil {
+		return "", ""
+	}
+	return strings.Join(diags, " and "), candidate
+}
+
+// trimPath returns the longest prefix of s, obtained by cutting s right before
+// a slash, question mark, or number sign that follows the scheme, that is a
+// valid origin pattern (regardless of case), along with a description of
+// what was cut off. The last result is false if no such prefix exists.
+// Longer prefixes are preferred so that an IP prefix in CIDR notation
+// (e.g. "http://10.0.0.0/8") doesn't get mistaken for a path.
+func trimPath(s string) (string, string, bool) {
+	start := strings.Index(s, schemeHostSep)
+	if start < 0 {
+		return "", "", false
+	}
+	start += len(schemeHostSep)
+	for i := len(s) - 1; i >= start; i-- {
+		if strings.IndexByte("/?#", s[i]) < 0 {
+			continue
+		}
+		prefix := s[:i]
+		if _, err := parsePattern(strings.ToLower(prefix)); err != nil {
+			continue
+		}
+		switch rest := s[i:]; {
+		case rest[0] != '/':
+			return prefix, diagQueryFragment, true
+		case rest == "/":
+			return prefix, diagTrailingSlash, true
+		default:
+			return prefix, diagPath, true
+		}
+	}
+	return "", "", false
+}
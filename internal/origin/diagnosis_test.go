@@ -0,0 +1,69 @@
+package origin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jub0bs/fcors/internal/util"
+)
+
+func TestParsePatternDiagnosis(t *testing.T) {
+	cases := []struct {
+		input      string
+		diagnosis  string
+		suggestion string
+	}{
+		{
+			input:      "https://example.com/",
+			diagnosis:  "trailing slash",
+			suggestion: "https://example.com",
+		}, {
+			input:      "https://example.com/api/v1",
+			diagnosis:  "path",
+			suggestion: "https://example.com",
+		}, {
+			input:      "https://example.com:8080?foo=bar",
+			diagnosis:  "query or fragment",
+			suggestion: "https://example.com:8080",
+		}, {
+			input:      "HTTPS://Example.com",
+			diagnosis:  "uppercase letters",
+			suggestion: "https://example.com",
+		}, {
+			input:      "example.com",
+			diagnosis:  "missing scheme",
+			suggestion: "https://example.com",
+		}, {
+			input:      "*.Example.com/",
+			diagnosis:  "missing scheme and trailing slash and uppercase letters",
+			suggestion: "https://*.example.com",
+		}, {
+			input:      "http://10.0.0.0/8/",
+			diagnosis:  "trailing slash",
+			suggestion: "http://10.0.0.0/8",
+		}, {
+			input: "https://example.com:06060",
+		}, {
+			input: "httpsfoo://example.com/",
+		},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			_, err := ParsePattern(c.input)
+			var ipe *util.InvalidOriginPatternError
+			if !errors.As(err, &ipe) {
+				t.Fatalf("got error %v; want *util.InvalidOriginPatternError", err)
+			}
+			if ipe.Pattern != c.input {
+				t.Errorf("got pattern %q; want %q", ipe.Pattern, c.input)
+			}
+			if ipe.Diagnosis != c.diagnosis {
+				t.Errorf("got diagnosis %q; want %q", ipe.Diagnosis, c.diagnosis)
+			}
+			if ipe.Suggestion != c.suggestion {
+				t.Errorf("got suggestion %q; want %q", ipe.Suggestion, c.suggestion)
+			}
+		}
+		t.Run(c.input, f)
+	}
+}
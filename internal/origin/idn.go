@@ -0,0 +1,147 @@
+package origin
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jub0bs/fcors/internal/util"
+)
+
+// toASCIIHostPattern converts the host part of s (which is assumed to start
+// at the beginning of s) from Unicode form to ASCII form, if need be.
+// It returns s with its host part converted, and an error.
+// Because browsers serialize origins in ASCII form
+// (see https://html.spec.whatwg.org/multipage/browsers.html#ascii-serialisation-of-an-origin),
+// converting host patterns to that form ensures that they match the
+// Origin header values that browsers send.
+func toASCIIHostPattern(s, full string) (string, error) {
+	var prefix string
+	if peekKind(s) == PatternKindSubdomains {
+		// *.example[.]com => example[.]com
+		n := len(subdomainWildcard) + 1 // 1 for label separator
+		prefix, s = s[:n], s[n:]
+	}
+	end := strings.IndexAny(s, ":/?#")
+	if end < 0 {
+		end = len(s)
+	}
+	host, rest := s[:end], s[end:]
+	if isASCII(host) {
+		return prefix + s, nil
+	}
+	if err := checkScripts(host, full); err != nil {
+		return "", err
+	}
+	host, err := profile.ToASCII(host)
+	if err != nil {
+		return "", util.InvalidOriginPatternErr(full)
+	}
+	return prefix + host + rest, nil
+}
+
+// checkIDNHost checks that host, which is assumed to be in ASCII form
+// and which may contain Punycode-encoded labels, is a valid
+// internationalized domain name whose labels don't mix scripts.
+// Punycode-encoded labels are thus held to the same standard
+// as labels specified in Unicode form.
+func checkIDNHost(host, full string) error {
+	// Unlike ToASCII, ToUnicode doesn't verify the length of host.
+	if _, err := profile.ToASCII(host); err != nil {
+		const tmpl = "invalid internationalized domain name in host: %q"
+		return util.Errorf(tmpl, full)
+	}
+	unicodeHost, err := profile.ToUnicode(host)
+	if err != nil {
+		const tmpl = "invalid internationalized domain name in host: %q"
+		return util.Errorf(tmpl, full)
+	}
+	return checkScripts(unicodeHost, full)
+}
+
+// checkScripts checks that none of the labels of host (in Unicode form)
+// mix scripts; see isMixedScript.
+func checkScripts(host, full string) error {
+	for _, label := range strings.Split(host, string(fullStop)) {
+		if isMixedScript(label) {
+			const tmpl = "prohibited mixed-script label %q in host: %q"
+			return util.Errorf(tmpl, label, full)
+		}
+	}
+	return nil
+}
+
+// isASCII returns true if s contains only ASCII bytes, and false otherwise.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// isMixedScript reports whether label mixes characters from different
+// scripts in a way that is typical of [homograph attacks]
+// (e.g. Latin and Cyrillic in "раypal").
+// Characters common to all scripts (e.g. digits and hyphens) are ignored.
+// In line with the "Highly Restrictive" level defined by [UTS #39],
+// the following combinations of scripts are tolerated:
+//
+//   - Latin + Han + Hiragana + Katakana
+//   - Latin + Han + Bopomofo
+//   - Latin + Han + Hangul
+//
+// [UTS #39]: https://www.unicode.org/reports/tr39/#Restriction_Level_Detection
+// [homograph attacks]: https://en.wikipedia.org/wiki/IDN_homograph_attack
+func isMixedScript(label string) bool {
+	scripts := make(util.Set[string])
+	for _, r := range label {
+		if script, ok := scriptOf(r); ok {
+			scripts.Add(script)
+		}
+	}
+	if len(scripts) <= 1 {
+		return false
+	}
+	for _, combination := range toleratedScriptCombinations {
+		if isSubset(scripts, combination) {
+			return false
+		}
+	}
+	return true
+}
+
+var toleratedScriptCombinations = []util.Set[string]{
+	util.NewSet("Latin", "Han", "Hiragana", "Katakana"),
+	util.NewSet("Latin", "Han", "Bopomofo"),
+	util.NewSet("Latin", "Han", "Hangul"),
+}
+
+// scriptOf returns the name of the script that r belongs to.
+// The second result is false if r is common to all scripts.
+func scriptOf(r rune) (string, bool) {
+	if unicode.In(r, unicode.Common, unicode.Inherited) {
+		return "", false
+	}
+	if r < utf8.RuneSelf || unicode.Is(unicode.Latin, r) {
+		return "Latin", true
+	}
+	for name, table := range unicode.Scripts {
+		if unicode.Is(table, r) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// isSubset returns true if all the elements of s are elements of t,
+// and false otherwise.
+func isSubset(s, t util.Set[string]) bool {
+	for e := range s {
+		if !t.Contains(e) {
+			return false
+		}
+	}
+	return true
+}
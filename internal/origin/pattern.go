@@ -1,13 +1,14 @@
 package origin
 
 import (
+	"errors"
 	"net/netip"
+	"strconv"
 	"strings"
 
 	"github.com/jub0bs/fcors/internal/radix"
 	"github.com/jub0bs/fcors/internal/util"
 	"golang.org/x/net/idna"
-	"golang.org/x/net/publicsuffix"
 )
 
 const (
@@ -22,6 +23,8 @@ const (
 	subdomainWildcard = "*"
 	// marks an arbitrary (possibly implicit) port number
 	portWildcard = "*"
+	// separates an IP address from a prefix length in CIDR notation
+	prefixLenSep = '/'
 	// sentinel value indicating that arbitrary port numbers are allowed
 	anyPort int = radix.WildcardElem
 )
@@ -32,9 +35,11 @@ type PatternKind uint8
 const (
 	// domain
 	PatternKindDomain PatternKind = iota
-	// non-loopback IP address
+	// non-loopback IP address,
+	// or IP prefix that encompasses some non-loopback IP addresses
 	PatternKindNonLoopbackIP
-	// loopback IP address
+	// loopback IP address,
+	// or IP prefix that encompasses only loopback IP addresses
 	PatternKindLoopbackIP
 	// arbitrary subdomains of depth one or more
 	PatternKindSubdomains
@@ -57,21 +62,145 @@ func (s *Pattern) IsDeemedInsecure() bool {
 		s.hostOnly() != "localhost"
 }
 
-func (s *Pattern) HostIsEffectiveTLD() (string, bool) {
+// HasArbitrarySubdomainsAndPorts reports whether s encompasses both
+// arbitrary subdomains and arbitrary ports, as in "https://*.example.com:*".
+func (s *Pattern) HasArbitrarySubdomainsAndPorts() bool {
+	return s.Kind == PatternKindSubdomains && s.Port == anyPort
+}
+
+// HostIsEffectiveTLD reports whether the host part of the pattern
+// (without any leading wildcard character sequence) is a public suffix
+// according to psl.
+func (s *Pattern) HostIsEffectiveTLD(psl PublicSuffixList) (string, bool) {
 	host := s.HostPattern.hostOnly()
 	// For cases like of a Web origin that ends with a full stop,
 	// we need to trim the latter for this check.
 	host = strings.TrimSuffix(host, string(fullStop))
-	// We ignore the second (boolean) result because
-	// it's false for some listed eTLDs (e.g. github.io)
-	etld, _ := publicsuffix.PublicSuffix(host)
+	// Note that DefaultPublicSuffixList's PublicSuffix method
+	// disregards whether eTLDs are ICANN-managed,
+	// which is desirable because some eTLDs (e.g. github.io) aren't.
+	etld := psl.PublicSuffix(host)
 	if etld == host {
 		return host, true
 	}
 	return "", false
 }
 
+// String returns the ASCII serialization of the origin pattern.
+// In particular, a pattern whose host was specified in Unicode form
+// is serialized with its host in ASCII (Punycode) form.
+func (s *Pattern) String() string {
+	var b strings.Builder
+	b.WriteString(s.Scheme)
+	b.WriteString(schemeHostSep)
+	addr, prefixLen, isPrefix := strings.Cut(s.Value, string(prefixLenSep))
+	if s.IsIP() && strings.IndexByte(addr, hostPortSep) >= 0 { // IPv6
+		b.WriteByte('[')
+		b.WriteString(addr)
+		b.WriteByte(']')
+	} else {
+		b.WriteString(addr)
+	}
+	if isPrefix {
+		b.WriteByte(prefixLenSep)
+		b.WriteString(prefixLen)
+	}
+	switch s.Port {
+	case 0: // no explicit port
+	case anyPort:
+		b.WriteByte(hostPortSep)
+		b.WriteString(portWildcard)
+	default:
+		b.WriteByte(hostPortSep)
+		b.WriteString(strconv.Itoa(s.Port))
+	}
+	return b.String()
+}
+
+// EncompassesSingleOrigin reports whether the origin pattern encompasses
+// exactly one origin.
+func (s *Pattern) EncompassesSingleOrigin() bool {
+	return s.Kind != PatternKindSubdomains &&
+		s.Port != anyPort &&
+		!s.IsIPPrefix()
+}
+
+// Overlaps reports whether some origin is encompassed
+// both by the origin pattern and by other.
+func (s *Pattern) Overlaps(other *Pattern) bool {
+	if s.Scheme != other.Scheme {
+		return false
+	}
+	if s.Port != other.Port && s.Port != anyPort && other.Port != anyPort {
+		return false
+	}
+	if s.IsIP() || other.IsIP() {
+		p1, ok1 := s.ipRange()
+		p2, ok2 := other.ipRange()
+		return ok1 && ok2 && p1.Overlaps(p2)
+	}
+	return s.HostPattern.overlapsDomain(&other.HostPattern)
+}
+
+// Encompasses reports whether all the origins encompassed by other
+// are also encompassed by the origin pattern.
+func (s *Pattern) Encompasses(other *Pattern) bool {
+	if s.Scheme != other.Scheme {
+		return false
+	}
+	if s.Port != anyPort && s.Port != other.Port {
+		return false
+	}
+	if s.IsIP() || other.IsIP() {
+		p1, ok1 := s.ipRange()
+		p2, ok2 := other.ipRange()
+		return ok1 && ok2 && p1.Bits() <= p2.Bits() && p1.Contains(p2.Addr())
+	}
+	return s.HostPattern.encompassesDomain(&other.HostPattern)
+}
+
+// LoopbackPatterns returns origin patterns that, together, encompass
+// all origins whose host is localhost or a loopback IP address,
+// regardless of their scheme and port. Note that some of those patterns
+// (those whose scheme is https and whose host is an IP prefix or address)
+// cannot be obtained via [ParsePattern].
+func LoopbackPatterns() []Pattern {
+	hosts := []HostPattern{
+		{Value: "localhost", Kind: PatternKindDomain},
+		{Value: "127.0.0.0/8", Kind: PatternKindLoopbackIP},
+		{Value: "::1", Kind: PatternKindLoopbackIP},
+	}
+	var patterns []Pattern
+	for _, scheme := range []string{schemeHTTP, schemeHTTPS} {
+		for _, host := range hosts {
+			pattern := Pattern{
+				Scheme:      scheme,
+				HostPattern: host,
+				Port:        anyPort,
+			}
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// ParsePattern parses s as an origin pattern. If s is invalid for a common
+// reason (e.g. a trailing slash), the resulting error is an
+// [util.InvalidOriginPatternError] that diagnoses the problem and suggests
+// a corrected origin pattern.
 func ParsePattern(s string) (*Pattern, error) {
+	p, err := parsePattern(s)
+	if err != nil {
+		var ipe *util.InvalidOriginPatternError
+		if errors.As(err, &ipe) {
+			ipe.Diagnosis, ipe.Suggestion = diagnose(s)
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func parsePattern(s string) (*Pattern, error) {
 	if s == "*" {
 		return nil, util.Errorf(`prohibited origin %q`, s)
 	}
@@ -105,11 +234,6 @@ func ParsePattern(s string) (*Pattern, error) {
 		if !ok || s != "" {
 			return nil, util.InvalidOriginPatternErr(full)
 		}
-		if port == anyPort && hostPattern.Kind == PatternKindSubdomains {
-			const tmpl = "specifying both arbitrary subdomains " +
-				"and arbitrary ports is prohibited: %q"
-			return nil, util.Errorf(tmpl, full)
-		}
 		if isDefaultPortForScheme(scheme, port) {
 			const tmpl = "default port %d for %q scheme " +
 				"needlessly specified: %q"
@@ -135,6 +259,11 @@ type HostPattern struct {
 // It returns the parsed host pattern, the unconsumed part of the input string,
 // and an error.
 func parseHostPattern(s, full string) (*HostPattern, string, error) {
+	ascii, err := toASCIIHostPattern(s, full)
+	if err != nil {
+		return nil, s, err
+	}
+	s = ascii
 	pattern := HostPattern{
 		Value: s, // temporary value, to be trimmed later
 		Kind:  peekKind(s),
@@ -177,6 +306,9 @@ func parseHostPattern(s, full string) (*HostPattern, string, error) {
 			const tmpl = "IP address in uncompressed form: %q"
 			return nil, s, util.Errorf(tmpl, full)
 		}
+		if rest, ok := consume(string(prefixLenSep), s); ok {
+			return parseIPPrefixPattern(ip, rest, full)
+		}
 
 		if ip.IsLoopback() {
 			pattern.Kind = PatternKindLoopbackIP
@@ -186,10 +318,8 @@ func parseHostPattern(s, full string) (*HostPattern, string, error) {
 		pattern.Value = ipStr
 		return &pattern, s, nil
 	}
-	_, err := profile.ToASCII(host.Value)
-	if err != nil {
-		const tmpl = "host not in ASCII form: %q"
-		return nil, s, util.Errorf(tmpl, full)
+	if err := checkIDNHost(host.Value, full); err != nil {
+		return nil, s, err
 	}
 	return &pattern, s, nil
 }
@@ -198,6 +328,128 @@ func (p *HostPattern) IsIP() bool {
 	return p.Kind == PatternKindLoopbackIP || p.Kind == PatternKindNonLoopbackIP
 }
 
+// IsIPPrefix reports whether the host pattern is an IP prefix
+// (e.g. 192.168.0.0/16) rather than a single IP address.
+func (p *HostPattern) IsIPPrefix() bool {
+	return p.IsIP() && strings.IndexByte(p.Value, prefixLenSep) >= 0
+}
+
+// ipPrefix returns the IP prefix that the host pattern represents, if any.
+func (p *HostPattern) ipPrefix() (netip.Prefix, bool) {
+	if !p.IsIPPrefix() {
+		return netip.Prefix{}, false
+	}
+	prefix, err := netip.ParsePrefix(p.Value)
+	return prefix, err == nil
+}
+
+// ipRange returns the range of IP addresses that the host pattern
+// represents, in the form of an IP prefix; a single IP address is
+// represented by a prefix whose length is that address's bit length.
+func (p *HostPattern) ipRange() (netip.Prefix, bool) {
+	if !p.IsIP() {
+		return netip.Prefix{}, false
+	}
+	if prefix, ok := p.ipPrefix(); ok {
+		return prefix, true
+	}
+	ip, err := netip.ParseAddr(p.Value)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	return netip.PrefixFrom(ip, ip.BitLen()), true
+}
+
+// overlapsDomain reports whether some domain is encompassed both by p
+// and by other, both of which are assumed not to be IP-based.
+func (p *HostPattern) overlapsDomain(other *HostPattern) bool {
+	if p.Kind != PatternKindSubdomains {
+		p, other = other, p
+	}
+	if p.Kind != PatternKindSubdomains { // neither p nor other is a wildcard
+		return p.Value == other.Value
+	}
+	base := p.hostOnly()
+	if other.Kind != PatternKindSubdomains {
+		return isProperSubdomainOf(other.Value, base)
+	}
+	otherBase := other.hostOnly()
+	return base == otherBase ||
+		isProperSubdomainOf(base, otherBase) ||
+		isProperSubdomainOf(otherBase, base)
+}
+
+// encompassesDomain reports whether all the domains encompassed by other
+// are also encompassed by p; both p and other are assumed not to be IP-based.
+func (p *HostPattern) encompassesDomain(other *HostPattern) bool {
+	if p.Kind != PatternKindSubdomains {
+		return other.Kind != PatternKindSubdomains && p.Value == other.Value
+	}
+	base := p.hostOnly()
+	if other.Kind != PatternKindSubdomains {
+		return isProperSubdomainOf(other.Value, base)
+	}
+	otherBase := other.hostOnly()
+	return base == otherBase || isProperSubdomainOf(otherBase, base)
+}
+
+// isProperSubdomainOf reports whether domain is a proper subdomain of base.
+func isProperSubdomainOf(domain, base string) bool {
+	rest, found := strings.CutSuffix(domain, base)
+	return found && len(rest) > 1 && rest[len(rest)-1] == fullStop
+}
+
+// parseIPPrefixPattern parses the prefix length that follows ip
+// in a host pattern in CIDR notation (e.g. 192.168.0.0/16).
+// It returns the parsed host pattern, the unconsumed part of the input string,
+// and an error.
+func parseIPPrefixPattern(ip netip.Addr, s, full string) (*HostPattern, string, error) {
+	const maxPrefixLenLen = len("128")
+	var (
+		bits int
+		i    int
+	)
+	for end := min(len(s), maxPrefixLenLen); i < end && isDigit(s[i]); i++ {
+		bits = 10*bits + intFromDigit(s[i])
+	}
+	if i == 0 || i > 1 && s[0] == '0' { // no digits, or leading zero
+		return nil, s, util.InvalidOriginPatternErr(full)
+	}
+	s = s[i:]
+	prefix := netip.PrefixFrom(ip, bits)
+	if !prefix.IsValid() {
+		return nil, s, util.InvalidOriginPatternErr(full)
+	}
+	if prefix.Masked() != prefix {
+		const tmpl = "IP prefix not in canonical form: %q"
+		return nil, s, util.Errorf(tmpl, full)
+	}
+	pattern := HostPattern{
+		Value: prefix.String(),
+		Kind:  PatternKindNonLoopbackIP,
+	}
+	if isLoopbackPrefix(prefix) {
+		pattern.Kind = PatternKindLoopbackIP
+	}
+	return &pattern, s, nil
+}
+
+// isLoopbackPrefix reports whether all the IP addresses encompassed
+// by prefix are loopback addresses.
+func isLoopbackPrefix(prefix netip.Prefix) bool {
+	// see https://www.rfc-editor.org/rfc/rfc5735#section-3
+	// and https://www.rfc-editor.org/rfc/rfc4291#section-2.5.3
+	const (
+		loopbackIPv4PrefixLen = 8
+		loopbackIPv6PrefixLen = 128
+	)
+	minLen := loopbackIPv4PrefixLen
+	if prefix.Addr().Is6() {
+		minLen = loopbackIPv6PrefixLen
+	}
+	return prefix.Addr().IsLoopback() && prefix.Bits() >= minLen
+}
+
 var profile = idna.New(
 	idna.BidiRule(),
 	idna.ValidateLabels(true),
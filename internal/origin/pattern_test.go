@@ -65,8 +65,49 @@ var parsePatternCases = []TestCase{
 		input:   "https://^foo",
 		failure: true,
 	}, {
-		name:    "host containing non-ASCII chars",
-		input:   "https://résumé.com",
+		name:  "host in Unicode form",
+		input: "https://résumé.com",
+		want: Pattern{
+			Scheme: "https",
+			HostPattern: HostPattern{
+				Value: "xn--rsum-bpad.com",
+			},
+		},
+	}, {
+		name:  "arbitrary subdomains of a host in Unicode form with port",
+		input: "https://*.bücher.example:8080",
+		want: Pattern{
+			Scheme: "https",
+			HostPattern: HostPattern{
+				Value: "*.xn--bcher-kva.example",
+				Kind:  PatternKindSubdomains,
+			},
+			Port: 8080,
+		},
+	}, {
+		name:  "host in Unicode form mixing Han, Hiragana, and Latin",
+		input: "https://例えtest.jp",
+		want: Pattern{
+			Scheme: "https",
+			HostPattern: HostPattern{
+				Value: "xn--test-p63cv06p.jp",
+			},
+		},
+	}, {
+		name:    "host in Unicode form mixing Cyrillic and Latin",
+		input:   "https://раypal.com",
+		failure: true,
+	}, {
+		name:    "host in Unicode form mixing Greek and Latin",
+		input:   "https://gοogle.com",
+		failure: true,
+	}, {
+		name:    "host in Unicode form containing uppercase letters",
+		input:   "https://Bücher.example",
+		failure: true,
+	}, {
+		name:    "host in Unicode form followed by a path",
+		input:   "https://bücher.example/foo",
 		failure: true,
 	}, {
 		name:    "invalid host char after label sep",
@@ -199,6 +240,95 @@ var parsePatternCases = []TestCase{
 			},
 			Port: 90,
 		},
+	}, {
+		name:  "non-loopback IPv4 prefix with arbitrary ports",
+		input: "http://192.168.0.0/16:*",
+		want: Pattern{
+			Scheme: "http",
+			HostPattern: HostPattern{
+				Value: "192.168.0.0/16",
+				Kind:  PatternKindNonLoopbackIP,
+			},
+			Port: anyPort,
+		},
+	}, {
+		name:  "loopback IPv4 prefix",
+		input: "http://127.0.0.0/8",
+		want: Pattern{
+			Scheme: "http",
+			HostPattern: HostPattern{
+				Value: "127.0.0.0/8",
+				Kind:  PatternKindLoopbackIP,
+			},
+		},
+	}, {
+		name:  "IPv4 prefix that encompasses the loopback range",
+		input: "http://64.0.0.0/2:9090",
+		want: Pattern{
+			Scheme: "http",
+			HostPattern: HostPattern{
+				Value: "64.0.0.0/2",
+				Kind:  PatternKindNonLoopbackIP,
+			},
+			Port: 9090,
+		},
+	}, {
+		name:  "non-loopback IPv6 prefix",
+		input: "http://[fd00::]/8:9090",
+		want: Pattern{
+			Scheme: "http",
+			HostPattern: HostPattern{
+				Value: "fd00::/8",
+				Kind:  PatternKindNonLoopbackIP,
+			},
+			Port: 9090,
+		},
+	}, {
+		name:  "loopback IPv6 prefix",
+		input: "http://[::1]/128",
+		want: Pattern{
+			Scheme: "http",
+			HostPattern: HostPattern{
+				Value: "::1/128",
+				Kind:  PatternKindLoopbackIP,
+			},
+		},
+	}, {
+		name:    "IPv4 prefix with host bits set",
+		input:   "http://192.168.1.0/16",
+		failure: true,
+	}, {
+		name:    "IPv4 prefix length too large",
+		input:   "http://192.168.0.0/33",
+		failure: true,
+	}, {
+		name:    "IPv6 prefix length too large",
+		input:   "http://[fd00::]/129",
+		failure: true,
+	}, {
+		name:    "IPv4 prefix length with leading zero",
+		input:   "http://192.168.0.0/016",
+		failure: true,
+	}, {
+		name:    "IPv4 prefix without length",
+		input:   "http://192.168.0.0/",
+		failure: true,
+	}, {
+		name:    "IPv4 prefix followed by junk",
+		input:   "http://192.168.0.0/16abc",
+		failure: true,
+	}, {
+		name:    "IPv6 prefix inside brackets",
+		input:   "http://[fd00::/8]:9090",
+		failure: true,
+	}, {
+		name:    "https scheme with IPv4 prefix",
+		input:   "https://192.168.0.0/16",
+		failure: true,
+	}, {
+		name:    "domain followed by prefix length",
+		input:   "http://example.com/16",
+		failure: true,
 	}, {
 		name:    "loopback IPv4 in nonstandard form",
 		input:   "http://127.1:3999",
@@ -267,9 +397,16 @@ var parsePatternCases = []TestCase{
 			Port: 3999,
 		},
 	}, {
-		name:    "arbitrary subdomains of depth one or more and arbitrary ports",
-		input:   "http://*.example.com:*",
-		failure: true,
+		name:  "arbitrary subdomains of depth one or more and arbitrary ports",
+		input: "http://*.example.com:*",
+		want: Pattern{
+			Scheme: "http",
+			HostPattern: HostPattern{
+				Value: "*.example.com",
+				Kind:  PatternKindSubdomains,
+			},
+			Port: anyPort,
+		},
 	}, {
 		name:    "leading double asterisk",
 		input:   "http://**.example.com:3999",
@@ -314,6 +451,202 @@ func TestParseSpec(t *testing.T) {
 	}
 }
 
+func TestString(t *testing.T) {
+	cases := []struct {
+		pattern string
+		want    string
+	}{
+		{
+			pattern: "https://example.com",
+			want:    "https://example.com",
+		}, {
+			pattern: "https://*.example.com:9090",
+			want:    "https://*.example.com:9090",
+		}, {
+			pattern: "http://localhost:*",
+			want:    "http://localhost:*",
+		}, {
+			pattern: "https://*.example.com:*",
+			want:    "https://*.example.com:*",
+		}, {
+			pattern: "http://127.0.0.1:90",
+			want:    "http://127.0.0.1:90",
+		}, {
+			pattern: "http://[::1]:90",
+			want:    "http://[::1]:90",
+		}, {
+			pattern: "https://www.éxample.com",
+			want:    "https://www.xn--xample-9ua.com",
+		}, {
+			pattern: "http://10.0.0.0/8:*",
+			want:    "http://10.0.0.0/8:*",
+		}, {
+			pattern: "http://[fd00::]/8:9090",
+			want:    "http://[fd00::]/8:9090",
+		},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			pattern, err := ParsePattern(c.pattern)
+			if err != nil {
+				t.Errorf("want nil error; got %v", err)
+				return
+			}
+			got := pattern.String()
+			if got != c.want {
+				t.Errorf("want %q; got %q", c.want, got)
+			}
+		}
+		t.Run(c.pattern, f)
+	}
+}
+
+func TestEncompassesSingleOrigin(t *testing.T) {
+	cases := []struct {
+		pattern string
+		want    bool
+	}{
+		{
+			pattern: "https://example.com",
+			want:    true,
+		}, {
+			pattern: "http://127.0.0.1:9090",
+			want:    true,
+		}, {
+			pattern: "https://*.example.com",
+			want:    false,
+		}, {
+			pattern: "http://localhost:*",
+			want:    false,
+		}, {
+			pattern: "http://10.0.0.0/8",
+			want:    false,
+		},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			pattern, err := ParsePattern(c.pattern)
+			if err != nil {
+				t.Errorf("want nil error; got %v", err)
+				return
+			}
+			got := pattern.EncompassesSingleOrigin()
+			if got != c.want {
+				t.Errorf("want %t; got %t", c.want, got)
+			}
+		}
+		t.Run(c.pattern, f)
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"https://example.com", "https://example.com", true},
+		{"https://example.com", "http://example.com", false},
+		{"https://example.com", "https://example.com:9090", false},
+		{"https://example.com:*", "https://example.com:9090", true},
+		{"https://example.com", "https://example.org", false},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "https://foo.example.com", true},
+		{"https://*.example.com", "https://foo.bar.example.com", true},
+		{"https://*.example.com", "https://fooexample.com", false},
+		{"https://*.example.com", "https://*.example.com:9090", false},
+		{"https://*.example.com", "https://*.foo.example.com", true},
+		{"https://*.example.com", "https://*.fooexample.com", false},
+		{"https://*.example.com:*", "https://foo.example.com:9090", true},
+		{"https://*.example.com:*", "https://example.com:9090", false},
+		{"http://localhost:*", "http://127.0.0.1:9090", false},
+		{"http://10.0.0.0/8", "http://10.1.2.3", true},
+		{"http://10.0.0.0/8", "http://11.1.2.3", false},
+		{"http://10.0.0.0/8", "http://10.1.0.0/16", true},
+		{"http://10.0.0.0/8", "http://[::1]", false},
+		{"http://[fd00::]/8:*", "http://[fd12::1]:9090", true},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			a, err := ParsePattern(c.a)
+			if err != nil {
+				t.Errorf("want nil error; got %v", err)
+				return
+			}
+			b, err := ParsePattern(c.b)
+			if err != nil {
+				t.Errorf("want nil error; got %v", err)
+				return
+			}
+			if got := a.Overlaps(b); got != c.want {
+				t.Errorf("%q versus %q: want %t; got %t", c.a, c.b, c.want, got)
+			}
+			if got := b.Overlaps(a); got != c.want {
+				t.Errorf("%q versus %q: want %t; got %t", c.b, c.a, c.want, got)
+			}
+		}
+		t.Run(c.a+" versus "+c.b, f)
+	}
+}
+
+func TestEncompasses(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"https://example.com", "https://example.com", true},
+		{"https://example.com", "http://example.com", false},
+		{"https://example.com", "https://example.com:9090", false},
+		{"https://example.com:9090", "https://example.com", false},
+		{"https://example.com:*", "https://example.com:9090", true},
+		{"https://example.com:9090", "https://example.com:*", false},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "https://foo.example.com", true},
+		{"https://foo.example.com", "https://*.example.com", false},
+		{"https://*.example.com", "https://*.foo.example.com", true},
+		{"https://*.foo.example.com", "https://*.example.com", false},
+		{"https://*.example.com", "https://*.fooexample.com", false},
+		{"https://*.example.com:*", "https://*.example.com:9090", true},
+		{"http://10.0.0.0/8", "http://10.1.0.0/16", true},
+		{"http://10.1.0.0/16", "http://10.0.0.0/8", false},
+		{"http://10.0.0.0/8", "http://10.1.2.3", true},
+		{"http://10.1.2.3", "http://10.1.2.3", true},
+		{"http://10.0.0.0/8", "http://[::1]", false},
+		{"http://localhost", "http://127.0.0.1", false},
+	}
+	for _, c := range cases {
+		f := func(t *testing.T) {
+			a, err := ParsePattern(c.a)
+			if err != nil {
+				t.Errorf("want nil error; got %v", err)
+				return
+			}
+			b, err := ParsePattern(c.b)
+			if err != nil {
+				t.Errorf("want nil error; got %v", err)
+				return
+			}
+			if got := a.Encompasses(b); got != c.want {
+				t.Errorf("%q versus %q: want %t; got %t", c.a, c.b, c.want, got)
+			}
+		}
+		t.Run(c.a+" versus "+c.b, f)
+	}
+}
+
+func TestParseHostPatternReturnsOriginalInputOnIDNError(t *testing.T) {
+	const (
+		input = "раypal.com:90"
+		full  = "https://" + input
+	)
+	_, rest, err := parseHostPattern(input, full)
+	if err == nil {
+		t.Fatal("got nil error; want non-nil error")
+	}
+	if rest != input {
+		t.Errorf("got %q; want %q", rest, input)
+	}
+}
+
 func TestIsDeemedInsecure(t *testing.T) {
 	cases := []struct {
 		pattern string
@@ -346,6 +679,18 @@ func TestIsDeemedInsecure(t *testing.T) {
 		}, {
 			pattern: "http://[2001:db8:aaaa:1111::100]:9090",
 			want:    true,
+		}, {
+			pattern: "http://127.0.0.0/8",
+			want:    false,
+		}, {
+			pattern: "http://126.0.0.0/7",
+			want:    true,
+		}, {
+			pattern: "http://10.0.0.0/8:*",
+			want:    true,
+		}, {
+			pattern: "http://[::]/0",
+			want:    true,
 		},
 	}
 	for _, c := range cases {
@@ -394,7 +739,7 @@ func TestHostIsEffectiveTLD(t *testing.T) {
 				t.Errorf("want non-nil error; got %v", err)
 				return
 			}
-			eTLD, isETLD := spec.HostIsEffectiveTLD()
+			eTLD, isETLD := spec.HostIsEffectiveTLD(DefaultPublicSuffixList)
 			if eTLD != c.eTLD || isETLD != c.isETLD {
 				t.Errorf("want %s, %t; got %s, %t", c.eTLD, c.isETLD, eTLD, isETLD)
 			}
@@ -0,0 +1,154 @@
+package origin
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/jub0bs/fcors/internal/util"
+	"golang.org/x/net/publicsuffix"
+)
+
+// A PublicSuffixList provides the public suffix of a domain.
+// Its method set is a subset of that of [net/http/cookiejar.PublicSuffixList].
+type PublicSuffixList interface {
+	// PublicSuffix returns the public suffix of domain.
+	PublicSuffix(domain string) string
+}
+
+// DefaultPublicSuffixList is the list of public suffixes maintained at
+// https://publicsuffix.org/, as embedded in golang.org/x/net/publicsuffix.
+var DefaultPublicSuffixList PublicSuffixList = publicsuffix.List
+
+const (
+	pslComment       = "//"
+	pslWildcard      = "*"
+	pslException     = "!"
+	pslLabelSep      = string(fullStop)
+	pslWildcardLabel = pslWildcard + pslLabelSep
+)
+
+// kinds of public-suffix rules
+const (
+	ruleNormal uint8 = 1 << iota
+	ruleWildcard
+	ruleException
+)
+
+// A SuffixList is a list of public-suffix rules
+// in the format described at https://github.com/publicsuffix/list/wiki/Format.
+type SuffixList struct {
+	// rules maps a rule (without any leading wildcard label or exclamation
+	// mark) to a bitmask of rule kinds.
+	rules map[string]uint8
+}
+
+// ParsePublicSuffixList parses data as a list of public-suffix rules
+// in the format described at https://github.com/publicsuffix/list/wiki/Format.
+// Rules in Unicode form are converted to ASCII form.
+// Argument name is only used in error messages.
+func ParsePublicSuffixList(name string, data []byte) (*SuffixList, error) {
+	list := SuffixList{
+		rules: make(map[string]uint8),
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	var lineNumber int
+	for sc.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, pslComment) {
+			continue
+		}
+		// Each rule ends at the first whitespace character.
+		rule, _, _ := strings.Cut(line, " ")
+		rule, _, _ = strings.Cut(rule, "\t")
+		kind := ruleNormal
+		if rest, ok := strings.CutPrefix(rule, pslException); ok {
+			kind = ruleException
+			rule = rest
+		} else if rest, ok := strings.CutPrefix(rule, pslWildcardLabel); ok {
+			kind = ruleWildcard
+			rule = rest
+		}
+		ascii, err := profile.ToASCII(strings.ToLower(rule))
+		if err != nil || ascii == "" || strings.Contains(ascii, pslWildcard) {
+			const tmpl = "invalid rule %q at line %d of public-suffix list %q"
+			return nil, util.Errorf(tmpl, line, lineNumber, name)
+		}
+		list.rules[ascii] |= kind
+	}
+	if err := sc.Err(); err != nil {
+		const tmpl = "failed to read public-suffix list %q: %v"
+		return nil, util.Errorf(tmpl, name, err)
+	}
+	return &list, nil
+}
+
+// PublicSuffix returns the public suffix of domain according to the
+// algorithm described at https://publicsuffix.org/list/.
+// In the absence of any matching rule, the prevailing rule is "*".
+func (l *SuffixList) PublicSuffix(domain string) string {
+	labels := strings.Split(domain, pslLabelSep)
+	// length (in labels) of the public suffix;
+	// the implicit "*" rule yields a public suffix of length 1.
+	n := 1
+	for i := len(labels) - 1; i >= 0; i-- {
+		suffix := strings.Join(labels[i:], pslLabelSep)
+		kind := l.rules[suffix]
+		depth := len(labels) - i
+		if kind&ruleException != 0 {
+			// Exception rules take precedence; the public suffix is
+			// the exception rule minus its leftmost label.
+			return strings.Join(labels[i+1:], pslLabelSep)
+		}
+		if kind&ruleNormal != 0 {
+			n = max(n, depth)
+		}
+		if kind&ruleWildcard != 0 && i > 0 {
+			n = max(n, depth+1)
+		}
+	}
+	return strings.Join(labels[len(labels)-n:], pslLabelSep)
+}
+
+// Union returns a PublicSuffixList whose result for a given domain is
+// the longest of the results of lists for that domain.
+// Union thus allows additional public-suffix rules to make lists stricter,
+// but never laxer.
+func Union(lists ...PublicSuffixList) PublicSuffixList {
+	return unionList(lists)
+}
+
+type unionList []PublicSuffixList
+
+func (ul unionList) PublicSuffix(domain string) string {
+	var longest string
+	for _, l := range ul {
+		if suffix := l.PublicSuffix(domain); len(suffix) > len(longest) {
+			longest = suffix
+		}
+	}
+	return longest
+}
+
+// EffectiveTLDPlusOne returns the effective top-level domain plus one more
+// label of domain, according to psl; it works like
+// [golang.org/x/net/publicsuffix.EffectiveTLDPlusOne] does.
+// The boolean result is false if domain is malformed
+// or is itself a public suffix.
+func EffectiveTLDPlusOne(psl PublicSuffixList, domain string) (string, bool) {
+	if strings.HasPrefix(domain, pslLabelSep) ||
+		strings.HasSuffix(domain, pslLabelSep) ||
+		strings.Contains(domain, pslLabelSep+pslLabelSep) {
+		return "", false
+	}
+	suffix := psl.PublicSuffix(domain)
+	if len(domain) <= len(suffix) {
+		return "", false
+	}
+	i := len(domain) - len(suffix) - 1
+	if domain[i] != fullStop {
+		return "", false
+	}
+	return domain[1+strings.LastIndexByte(domain[:i], fullStop):], true
+}
@@ -0,0 +1,106 @@
+package origin
+
+import "testing"
+
+const testPublicSuffixList = `// comments and blank lines are ignored
+
+com
+co.uk
+apps.example.corp   trailing text is ignored
+*.ck
+!www.ck
+// rules in Unicode form are converted to ASCII form
+ακ.gr
+`
+
+func TestParsePublicSuffixList(t *testing.T) {
+	list, err := ParsePublicSuffixList("test", []byte(testPublicSuffixList))
+	if err != nil {
+		t.Fatalf("got error %v; want nil error", err)
+	}
+	cases := []struct {
+		domain string
+		want   string
+	}{
+		{domain: "com", want: "com"},
+		{domain: "example.com", want: "com"},
+		{domain: "example.co.uk", want: "co.uk"},
+		{domain: "foo.apps.example.corp", want: "apps.example.corp"},
+		{domain: "example.corp", want: "corp"},
+		{domain: "foo.bar.ck", want: "bar.ck"},
+		{domain: "ck", want: "ck"},
+		{domain: "www.ck", want: "ck"},
+		{domain: "foo.www.ck", want: "ck"},
+		{domain: "example.xn--mxas.gr", want: "xn--mxas.gr"},
+		{domain: "example.org", want: "org"},
+	}
+	for _, c := range cases {
+		if got := list.PublicSuffix(c.domain); got != c.want {
+			const tmpl = "%q: got %q; want %q"
+			t.Errorf(tmpl, c.domain, got, c.want)
+		}
+	}
+}
+
+func TestParsePublicSuffixListInvalid(t *testing.T) {
+	cases := []string{
+		"*",
+		"!",
+		"foo.*.com",
+		"*.*.corp",
+		"foo..com",
+	}
+	for _, rule := range cases {
+		if _, err := ParsePublicSuffixList("test", []byte(rule)); err == nil {
+			t.Errorf("%q: got nil error; want non-nil error", rule)
+		}
+	}
+}
+
+func TestUnion(t *testing.T) {
+	list, err := ParsePublicSuffixList("test", []byte("apps.example.corp\n"))
+	if err != nil {
+		t.Fatalf("got error %v; want nil error", err)
+	}
+	union := Union(DefaultPublicSuffixList, list)
+	cases := []struct {
+		domain string
+		want   string
+	}{
+		{domain: "foo.apps.example.corp", want: "apps.example.corp"},
+		{domain: "jub0bs.github.io", want: "github.io"},
+		{domain: "example.com", want: "com"},
+	}
+	for _, c := range cases {
+		if got := union.PublicSuffix(c.domain); got != c.want {
+			const tmpl = "%q: got %q; want %q"
+			t.Errorf(tmpl, c.domain, got, c.want)
+		}
+	}
+}
+
+func TestEffectiveTLDPlusOne(t *testing.T) {
+	cases := []struct {
+		domain string
+		want   string
+		ok     bool
+	}{
+		{domain: "example.com", want: "example.com", ok: true},
+		{domain: "foo.bar.example.com", want: "example.com", ok: true},
+		{domain: "foo.example.co.uk", want: "example.co.uk", ok: true},
+		{domain: "jub0bs.github.io", want: "jub0bs.github.io", ok: true},
+		{domain: "com"},
+		{domain: "github.io"},
+		{domain: ""},
+		{domain: ".example.com"},
+		{domain: "example.com."},
+		{domain: "foo..example.com"},
+	}
+	for _, c := range cases {
+		got, ok := EffectiveTLDPlusOne(DefaultPublicSuffixList, c.domain)
+		if got != c.want || ok != c.ok {
+			const tmpl = "%q: got %q, %t; want %q, %t"
+			t.Errorf(tmpl, c.domain, got, ok, c.want, c.ok)
+		}
+	}
+}
@@ -330,6 +330,31 @@ func TestRadix(t *testing.T) {
 				{"pkin", 0},
 				{"kpin", 0},
 			},
+		}, {
+			desc: "wildcard-full pattern with wildcard value among other patterns",
+			patterns: []Pair{
+				{"example.com", 443},
+				{"*.le.com", 8080},
+				{"*.example.com", -1},
+			},
+			accept: []Pair{
+				{"example.com", 443},
+				{"foo.le.com", 8080},
+				// extended key, arbitrary value
+				{"foo.example.com", 0},
+				{"foo.example.com", 443},
+				{"foo.example.com", 8080},
+				{"bar.foo.example.com", 9999},
+			},
+			reject: []Pair{
+				// different value
+				{"example.com", 8080},
+				{"foo.le.com", 443},
+				// key not extended by a non-empty byte sequence
+				{".example.com", 0},
+				// key extended but without the label separator
+				{"fooexample.com", 0},
+			},
 		}, {
 			desc: "some wildcard-full patterns and wildcard value",
 			patterns: []Pair{
@@ -33,5 +33,28 @@ func NewErrorRisky(text string) error {
 
 // InvalidOriginPatternErr returns an error about invalid origin pattern s.
 func InvalidOriginPatternErr(s string) error {
-	return Errorf("invalid origin pattern %q", s)
+	return &InvalidOriginPatternError{Pattern: s}
+}
+
+// An InvalidOriginPatternError describes an invalid origin pattern.
+// When the problem can be diagnosed, it also provides a corrected
+// origin pattern.
+type InvalidOriginPatternError struct {
+	// Pattern is the invalid origin pattern, as specified.
+	Pattern string
+	// Diagnosis describes the problem (e.g. "trailing slash"), if identified;
+	// otherwise, it is empty.
+	Diagnosis string
+	// Suggestion is the valid origin pattern that was likely intended,
+	// if any; it is empty if and only if Diagnosis is empty.
+	Suggestion string
+}
+
+func (e *InvalidOriginPatternError) Error() string {
+	msg := fmt.Sprintf("%s: invalid origin pattern %q", pkgFcors, e.Pattern)
+	if e.Diagnosis == "" {
+		return msg
+	}
+	const tmpl = "%s: %s; did you mean %q?"
+	return fmt.Sprintf(tmpl, msg, e.Diagnosis, e.Suggestion)
 }
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"slices"
+	"time"
+)
+
+// A ValidityWindow describes the origin patterns specified in an occurrence
+// of option FromOriginsUntil or FromOriginsBetween.
+type ValidityWindow struct {
+	// Patterns contains the origin patterns (in their ASCII serialization),
+	// in lexicographical order.
+	Patterns []string
+	// NotBefore is the time (inclusive) from which the origin patterns match;
+	// the zero value indicates the absence of a lower bound.
+	NotBefore time.Time
+	// NotAfter is the time (exclusive) until which the origin patterns match.
+	NotAfter time.Time
+}
+
+// Remaining returns how long after now the origin patterns stop matching;
+// the result is not positive if they already have.
+func (vw *ValidityWindow) Remaining(now time.Time) time.Duration {
+	return vw.NotAfter.Sub(now)
+}
+
+func ValidityWindows[A applier](cred bool, one A, others ...A) ([]ValidityWindow, error) {
+	cfg, err := newValidConfig(cred, one, others...)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.validityWindows(), nil
+}
+
+// validityWindows returns the validity windows of the time-bounded
+// origin patterns of cfg, which is assumed to be valid and not to have been
+// subjected to precomputeStuff yet, in the order in which they were
+// specified.
+func (cfg *Config) validityWindows() []ValidityWindow {
+	var windows []ValidityWindow
+	for _, to := range cfg.tmp.TimedOrigins {
+		patterns := make([]string, 0, len(to.patterns))
+		for pattern := range to.patterns {
+			patterns = append(patterns, pattern.String())
+		}
+		slices.Sort(patterns)
+		vw := ValidityWindow{
+			Patterns:  patterns,
+			NotBefore: to.notBefore,
+			NotAfter:  to.notAfter,
+		}
+		windows = append(windows, vw)
+	}
+	return windows
+}
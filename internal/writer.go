@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// serve lets h handle r. If need be, it wraps w so as to adjust
+// the response headers that h sets.
+func (cfg *Config) serve(h http.Handler, w http.ResponseWriter, r *http.Request) {
+	_, allowed := w.Header()[headerAllowOrigin]
+	// If access is denied, there is no need to expose anything.
+	expose := cfg.ExposeResponseHeadersAutomatically && allowed
+	var cacheControl []string
+	if cfg.PreventSharedCaching && cfg.ACAO == nil {
+		// The response depends on the request's origin.
+		cacheControl = privateDirectives
+	}
+	cfg.serveAdjusting(h, w, r, expose, cacheControl, 0)
+}
+
+// serveAdjusting lets h handle r. If expose is true, it exposes the
+// response headers that h sets; if cacheControl is non-nil, it merges
+// the directives it contains into the Cache-Control header that h sets;
+// if defaultStatus is non-zero, it is the response status used if h
+// doesn't explicitly write one.
+func (*Config) serveAdjusting(
+	h http.Handler,
+	w http.ResponseWriter,
+	r *http.Request,
+	expose bool,
+	cacheControl []string,
+	defaultStatus int,
+) {
+	if !expose && cacheControl == nil && defaultStatus == 0 {
+		h.ServeHTTP(w, r)
+		return
+	}
+	aw := adjustingResponseWriter{
+		ResponseWriter: w,
+		expose:         expose,
+		cacheControl:   cacheControl,
+		defaultStatus:  defaultStatus,
+	}
+	h.ServeHTTP(&aw, r)
+	// If h wrote neither headers nor body, package net/http writes the
+	// response headers once h returns, which leaves us a last chance.
+	aw.writeDefaultHeader()
+	aw.adjustHeaders()
+}
+
+// An adjustingResponseWriter adjusts the response headers that were set
+// by the time the response status was written.
+type adjustingResponseWriter struct {
+	http.ResponseWriter
+	// whether the Access-Control-Expose-Headers header should be set
+	expose bool
+	// Cache-Control directives to merge into the response, if any
+	cacheControl []string
+	// status to write if the handler doesn't write one, if non-zero
+	defaultStatus int
+	adjusted      bool
+	// whether the final response status was written
+	wroteHeader bool
+}
+
+func (w *adjustingResponseWriter) WriteHeader(statusCode int) {
+	// Informational (1xx) responses precede the final response;
+	// see https://pkg.go.dev/net/http#ResponseWriter.
+	if statusCode >= http.StatusOK {
+		w.adjustHeaders()
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *adjustingResponseWriter) Write(b []byte) (int, error) {
+	w.writeDefaultHeader()
+	w.adjustHeaders()
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements [http.Flusher]; it is a no-op if the underlying
+// [http.ResponseWriter] doesn't support flushing.
+func (w *adjustingResponseWriter) Flush() {
+	w.writeDefaultHeader()
+	w.adjustHeaders()
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements [http.Hijacker]; it fails if the underlying
+// [http.ResponseWriter] doesn't support hijacking.
+func (w *adjustingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		// The handler took over the connection;
+		// there is no longer any response status to write.
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap allows [http.ResponseController] to access the underlying
+// [http.ResponseWriter].
+func (w *adjustingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// writeDefaultHeader writes the default status (if any),
+// unless the final response status was already written.
+func (w *adjustingResponseWriter) writeDefaultHeader() {
+	if w.wroteHeader || w.defaultStatus == 0 {
+		return
+	}
+	w.WriteHeader(w.defaultStatus)
+}
+
+func (w *adjustingResponseWriter) adjustHeaders() {
+	if w.adjusted {
+		return
+	}
+	w.adjusted = true
+	respHeaders := w.ResponseWriter.Header()
+	if w.expose {
+		exposeHeaders(respHeaders)
+	}
+	if w.cacheControl != nil {
+		mergeCacheControl(respHeaders, w.cacheControl)
+	}
+}
@@ -0,0 +1,106 @@
+package origin_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jub0bs/fcors/origin"
+)
+
+func ExampleParsePattern() {
+	for _, raw := range []string{
+		"https://example.com",
+		"https://*.bücher.example:9090",
+		"http://192.168.0.0/16:*",
+		"https://example.com/",
+		"https://*.github.io",
+	} {
+		p, err := origin.ParsePattern(raw)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		_, isPublicSuffix := p.SubdomainsOfPublicSuffix()
+		const tmpl = "%s (insecure: %t; subdomains of public suffix: %t)\n"
+		fmt.Printf(tmpl, p, p.IsDeemedInsecure(), isPublicSuffix)
+	}
+	// Output:
+	// https://example.com (insecure: false; subdomains of public suffix: false)
+	// https://*.xn--bcher-kva.example:9090 (insecure: false; subdomains of public suffix: false)
+	// http://192.168.0.0/16:* (insecure: true; subdomains of public suffix: false)
+	// fcors: invalid origin pattern "https://example.com/": trailing slash; did you mean "https://example.com"?
+	// https://*.github.io (insecure: false; subdomains of public suffix: true)
+}
+
+func ExampleInvalidPatternError() {
+	for _, raw := range []string{
+		"HTTPS://Example.com/api",
+		"example.com:8080",
+		"https://example.com:06060",
+	} {
+		_, err := origin.ParsePattern(raw)
+		var ipe *origin.InvalidPatternError
+		if !errors.As(err, &ipe) {
+			continue
+		}
+		if ipe.Diagnosis == "" {
+			fmt.Printf("%q: no diagnosis\n", ipe.Pattern)
+			continue
+		}
+		fmt.Printf("%q: %s; try %q\n", ipe.Pattern, ipe.Diagnosis, ipe.Suggestion)
+	}
+	// Output:
+	// "HTTPS://Example.com/api": path and uppercase letters; try "https://example.com"
+	// "example.com:8080": missing scheme; try "https://example.com:8080"
+	// "https://example.com:06060": no diagnosis
+}
+
+func ExampleParse() {
+	for _, raw := range []string{
+		"https://example.com:9090",
+		"http://[::1]",
+		"https://example.com/",
+	} {
+		o, err := origin.Parse(raw)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Printf("%+v\n", o)
+	}
+	// Output:
+	// {Scheme:https Host:example.com Port:9090}
+	// {Scheme:http Host:::1 Port:0}
+	// fcors: invalid origin "https://example.com/"
+}
+
+func ExampleNewMatcher() {
+	mustParse := func(raw string) *origin.Pattern {
+		p, err := origin.ParsePattern(raw)
+		if err != nil {
+			panic(err)
+		}
+		return p
+	}
+	allowed := []*origin.Pattern{
+		mustParse("https://*.example.com"),
+		mustParse("http://localhost:*"),
+	}
+	excluded := []*origin.Pattern{
+		mustParse("https://uploads.example.com"),
+	}
+	m := origin.NewMatcher(allowed, excluded)
+	for _, o := range []string{
+		"https://foo.example.com",
+		"https://uploads.example.com",
+		"https://example.com",
+		"http://localhost:9090",
+	} {
+		fmt.Printf("%s: %t\n", o, m.Match(o))
+	}
+	// Output:
+	// https://foo.example.com: true
+	// https://uploads.example.com: false
+	// https://example.com: false
+	// http://localhost:9090: true
+}
@@ -0,0 +1,143 @@
+// Package origin exposes the parsing and matching logic for [Web origins]
+// and origin patterns that the middleware provided by package
+// [github.com/jub0bs/fcors] rely on.
+// It is useful, for instance, for validating a CORS configuration
+// (e.g. in deployment tooling) before any middleware gets built.
+//
+// The syntax of origin patterns is documented in
+// [github.com/jub0bs/fcors.FromOrigins].
+//
+// [Web origins]: https://developer.mozilla.org/en-US/docs/Glossary/Origin
+package origin
+
+import (
+	internal "github.com/jub0bs/fcors/internal/origin"
+	"github.com/jub0bs/fcors/internal/util"
+)
+
+// A Pattern represents a valid origin pattern.
+// The zero value of a Pattern is not a valid origin pattern;
+// use [ParsePattern] to obtain a Pattern.
+type Pattern struct {
+	p internal.Pattern
+}
+
+// ParsePattern parses s as an origin pattern.
+// It accepts exactly the same origin patterns as
+// [github.com/jub0bs/fcors.FromOrigins] does (in the absence of
+// any option from package [github.com/jub0bs/fcors/risky]) and
+// fails with the same errors. If s is invalid for a common reason
+// (e.g. a trailing slash or a missing scheme), the resulting error
+// is an [*InvalidPatternError] that diagnoses the problem and suggests a
+// corrected origin pattern.
+func ParsePattern(s string) (*Pattern, error) {
+	p, err := internal.ParsePattern(s)
+	if err != nil {
+		return nil, err
+	}
+	if p.HasArbitrarySubdomainsAndPorts() {
+		const tmpl = "specifying both arbitrary subdomains " +
+			"and arbitrary ports is prohibited: %q"
+		return nil, util.Errorf(tmpl, s)
+	}
+	return &Pattern{p: *p}, nil
+}
+
+// An InvalidPatternError describes an invalid origin pattern.
+// Use [errors.As] to extract one from the errors returned by [ParsePattern],
+// [github.com/jub0bs/fcors.AllowAccess], and
+// [github.com/jub0bs/fcors.AllowAccessWithCredentials].
+type InvalidPatternError = util.InvalidOriginPatternError
+
+// String returns the ASCII serialization of p.
+// In particular, if p was specified with its host in Unicode form,
+// the result contains that host in ASCII (Punycode) form.
+func (p *Pattern) String() string {
+	return p.p.String()
+}
+
+// IsDeemedInsecure reports whether p is deemed insecure,
+// i.e. whether its scheme is http and its host is neither localhost
+// nor a loopback IP address (or a prefix of loopback IP addresses).
+// Insecure origin patterns are by default prohibited when credentialed access
+// and/or Private-Network Access is enabled.
+func (p *Pattern) IsDeemedInsecure() bool {
+	return p.p.IsDeemedInsecure()
+}
+
+// SubdomainsOfPublicSuffix reports whether p encompasses arbitrary
+// subdomains of a [public suffix]; if so, it also returns that public suffix.
+// Such origin patterns are by default prohibited.
+//
+// [public suffix]: https://publicsuffix.org/
+func (p *Pattern) SubdomainsOfPublicSuffix() (string, bool) {
+	if p.p.Kind != internal.PatternKindSubdomains {
+		return "", false
+	}
+	return p.p.HostIsEffectiveTLD(internal.DefaultPublicSuffixList)
+}
+
+// Overlaps reports whether some origin is encompassed both by p and by other.
+func (p *Pattern) Overlaps(other *Pattern) bool {
+	return p.p.Overlaps(&other.p)
+}
+
+// An Origin represents a (tuple) Web origin.
+type Origin struct {
+	// Scheme is the origin's scheme (either "http" or "https").
+	Scheme string
+	// Host is the origin's host (without brackets, in the case of an IPv6
+	// address).
+	Host string
+	// Port is the origin's port (if any).
+	// The zero value marks the absence of an explicit port.
+	Port int
+}
+
+// Parse parses s as a Web origin, as it would appear in the value of
+// an Origin request header.
+// Like the middleware provided by package [github.com/jub0bs/fcors],
+// Parse is lenient insofar as it performs just enough validation for
+// [Matcher.Match] to know what to do with the resulting Origin value.
+// In particular, the scheme and port of the resulting origin are guaranteed
+// to be valid, but its host isn't.
+func Parse(s string) (Origin, error) {
+	o, ok := internal.Parse(s)
+	if !ok {
+		return Origin{}, util.Errorf("invalid origin %q", s)
+	}
+	origin := Origin{
+		Scheme: o.Scheme,
+		Host:   o.Value,
+		Port:   o.Port,
+	}
+	return origin, nil
+}
+
+// A Matcher represents a set of origins. It is safe for concurrent use
+// by multiple goroutines.
+type Matcher struct {
+	corpus internal.Corpus
+}
+
+// NewMatcher returns a Matcher for the origins that are encompassed by
+// at least one of the patterns in allowed but by none of the patterns in
+// excluded. A Matcher thus has the same semantics as the combination of
+// options [github.com/jub0bs/fcors.FromOrigins] and
+// [github.com/jub0bs/fcors.ExceptOrigins].
+func NewMatcher(allowed []*Pattern, excluded []*Pattern) *Matcher {
+	var m Matcher
+	for _, p := range allowed {
+		m.corpus.Add(&p.p)
+	}
+	for _, p := range excluded {
+		m.corpus.Exclude(&p.p)
+	}
+	return &m
+}
+
+// Match reports whether the origin s belongs to m.
+func (m *Matcher) Match(s string) bool {
+	o, ok := internal.Parse(s)
+	return ok && m.corpus.Contains(&o)
+}
@@ -5,6 +5,8 @@
 package risky
 
 import (
+	"io/fs"
+
 	"github.com/jub0bs/fcors"
 	"github.com/jub0bs/fcors/internal"
 )
@@ -78,3 +80,67 @@ func DangerouslyTolerateInsecureOrigins() fcors.Option {
 func DangerouslyTolerateSubdomainsOfPublicSuffixes() fcors.Option {
 	return internal.DangerouslyTolerateSubdomainsOfPublicSuffixes()
 }
+
+// DangerouslyTolerateSubdomainsWithArbitraryPorts enables you to specify,
+// in option [github.com/jub0bs/fcors.FromOrigins], origin patterns that
+// encompass both arbitrary subdomains and arbitrary ports, like
+//
+//	https://*.dev.example.com:*
+//
+// which option [github.com/jub0bs/fcors.FromOrigins] by default prohibits.
+// Be aware that such origin patterns encompass a great many origins,
+// including those of services (e.g. debugging tools or admin consoles)
+// that may be running on arbitrary ports of any subdomain and that may be
+// less trustworthy than your main application.
+//
+// Regardless of this option, origin patterns that encompass both
+// arbitrary subdomains of a [public suffix] and arbitrary ports
+// are prohibited, even if option
+// [DangerouslyTolerateSubdomainsOfPublicSuffixes] is also activated.
+//
+// [public suffix]: https://publicsuffix.org/
+func DangerouslyTolerateSubdomainsWithArbitraryPorts() fcors.Option {
+	return internal.DangerouslyTolerateSubdomainsWithArbitraryPorts()
+}
+
+// TrustXForwardedHost configures a CORS middleware to determine the host
+// targeted by a request from the first element of the request's
+// [X-Forwarded-Host] header (if present) rather than from its Host header.
+// This option only affects options
+// [github.com/jub0bs/fcors.FromSameSiteOrigins] and
+// [github.com/jub0bs/fcors.FromOriginsForHost].
+// Only activate this option if your server is exclusively reachable through
+// a trusted proxy that sets or overwrites the X-Forwarded-Host header;
+// otherwise, attackers can trivially spoof that header in order to be
+// deemed same site with your server.
+//
+// When this option is used in conjunction with either of those options,
+// the middleware lists X-Forwarded-Host in the Vary header of its responses,
+// since which origins are allowed then depends on that header.
+//
+// [X-Forwarded-Host]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/X-Forwarded-Host
+func TrustXForwardedHost() fcors.Option {
+	return internal.TrustXForwardedHost()
+}
+
+// ReplacePublicSuffixList configures a CORS middleware to treat as
+// [public suffixes] the domains listed in the file named name in file system
+// fsys (rather than those listed at https://publicsuffix.org/) when checking
+// the origin patterns specified in option [github.com/jub0bs/fcors.FromOrigins].
+// That file must follow [the format of the Public Suffix List].
+// Be aware that omitting from that file some domains that are
+// public suffixes in practice makes it possible to inadvertently allow
+// all subdomains of such domains, which are typically registrable by anyone,
+// including attackers. If all you need is to treat additional domains as
+// public suffixes, use option
+// [github.com/jub0bs/fcors.AdditionalPublicSuffixes] instead.
+//
+// Any failure to read or parse the file results in a failure to build
+// the corresponding middleware; so does using this option in conjunction
+// with option [github.com/jub0bs/fcors.AdditionalPublicSuffixes].
+//
+// [public suffixes]: https://publicsuffix.org/
+// [the format of the Public Suffix List]: https://github.com/publicsuffix/list/wiki/Format
+func ReplacePublicSuffixList(fsys fs.FS, name string) fcors.Option {
+	return internal.ReplacePublicSuffixList(fsys, name)
+}